@@ -6,6 +6,19 @@ CREATE TABLE `users` (
     `last_name` VARCHAR(40),
     `created_at` DATETIME(6) NOT NULL,
     `updated_at` DATETIME(6) NOT NULL,
+    `version` INT NOT NULL DEFAULT 1,
+    `deleted_at` DATETIME(6),
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE `projects` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT,
+    `name` VARCHAR(30) NOT NULL,
+    `description` TEXT NOT NULL,
+    `color` VARCHAR(7) NOT NULL,
+    `archived` BOOLEAN NOT NULL DEFAULT FALSE,
+    `created_at` DATETIME(6) NOT NULL,
+    `updated_at` DATETIME(6) NOT NULL,
     PRIMARY KEY (`id`)
 );
 
@@ -16,8 +29,42 @@ CREATE TABLE `tasks` (
     `due_date` DATE,
     `status` VARCHAR(10),
     `owner_id` BIGINT,
+    `project_id` BIGINT,
+    `archived` BOOLEAN NOT NULL DEFAULT FALSE,
     `created_at` DATETIME(6) NOT NULL,
     `updated_at` DATETIME(6) NOT NULL,
+    `version` INT NOT NULL DEFAULT 1,
+    `deleted_at` DATETIME(6),
+    `deleted_by_cascade` BOOLEAN NOT NULL DEFAULT FALSE,
+    `completed_at` DATETIME(6),
     PRIMARY KEY (`id`),
-    FOREIGN KEY (`owner_id`) REFERENCES `users`(`id`)
-);
\ No newline at end of file
+    FOREIGN KEY (`owner_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`project_id`) REFERENCES `projects`(`id`)
+);
+
+CREATE TABLE `task_history` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT,
+    `task_id` BIGINT NOT NULL,
+    `action` VARCHAR(10) NOT NULL,
+    `field` VARCHAR(30) NOT NULL,
+    `old_value` TEXT,
+    `new_value` TEXT,
+    `actor` VARCHAR(255) NOT NULL,
+    `created_at` DATETIME(6) NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_task_history_task_id` (`task_id`, `id`)
+);
+
+CREATE TABLE `idempotency_keys` (
+    `actor` VARCHAR(255) NOT NULL,
+    `idempotency_key` VARCHAR(255) NOT NULL,
+    `fingerprint` CHAR(64) NOT NULL,
+    `status_code` INT NOT NULL DEFAULT 0,
+    `content_type` VARCHAR(255) NOT NULL DEFAULT '',
+    `etag` VARCHAR(255) NOT NULL DEFAULT '',
+    `response_body` MEDIUMBLOB,
+    `created_at` DATETIME(6) NOT NULL,
+    `expires_at` DATETIME(6) NOT NULL,
+    PRIMARY KEY (`actor`, `idempotency_key`),
+    KEY `idx_idempotency_keys_expires_at` (`expires_at`)
+);
@@ -2,28 +2,64 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/kenwoo9y/todo-api-go/api/internal/config"
 	"github.com/kenwoo9y/todo-api-go/api/internal/db"
 	"github.com/kenwoo9y/todo-api-go/api/internal/handler"
+	"github.com/kenwoo9y/todo-api-go/api/internal/metrics"
+	"github.com/kenwoo9y/todo-api-go/api/internal/ratelimit"
 	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/internal/scheduler"
 	"github.com/kenwoo9y/todo-api-go/api/internal/server"
+	"github.com/kenwoo9y/todo-api-go/api/internal/tlsconfig"
+	"github.com/kenwoo9y/todo-api-go/api/internal/tracing"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "calendar-url" {
+		if err := printCalendarURL(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := run(context.Background()); err != nil {
-		log.Fatal(err)
+		slog.Error("server stopped", slog.Any("error", err))
+		os.Exit(1)
 	}
 }
 
+// printCalendarURL prints the calendar feed path of a user. Feed tokens are only issued here, by an
+// operator with access to CALENDAR_SECRET, as the API cannot tell who is asking for them.
+func printCalendarURL(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: calendar-url <user id>")
+	}
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || userID <= 0 {
+		return fmt.Errorf("invalid user id %q", args[0])
+	}
+	secret := os.Getenv("CALENDAR_SECRET")
+	if secret == "" {
+		return errors.New("CALENDAR_SECRET is not set, so calendar feeds are disabled")
+	}
+
+	fmt.Println(handler.CalendarFeedURL(secret, userID))
+	return nil
+}
+
 func run(ctx context.Context) error {
 	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer stop()
@@ -32,6 +68,7 @@ func run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	slog.SetDefault(newLogger(cfg))
 
 	// Connect to database
 	database, err := db.NewDB()
@@ -40,40 +77,145 @@ func run(ctx context.Context) error {
 	}
 	defer database.Close()
 
+	// Initialize metrics
+	registry := metrics.NewRegistry()
+	metrics.RegisterDBStats(registry, database, cfg.DBName)
+
+	// Initialize tracing, which is disabled when no OTLP endpoint is configured
+	tracerProvider, err := tracing.New(cfg)
+	if err != nil {
+		return err
+	}
+	if tracerProvider != nil {
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := tracerProvider.Shutdown(ctx); err != nil {
+				slog.Error("failed to flush spans", slog.Any("error", err))
+			}
+		}()
+	}
+	observer := repository.Observers{tracing.NewRepositoryObserver(tracerProvider, cfg), metrics.NewRepositoryObserver(registry)}
+
 	// Initialize repositories
-	userRepo := repository.NewUserRepository(database, cfg)
-	taskRepo := repository.NewTaskRepository(database, cfg)
+	userRepo := repository.InstrumentUserRepository(repository.NewUserRepository(database, cfg), observer)
+	taskRepo := repository.InstrumentTaskRepository(repository.NewTaskRepository(database, cfg), observer)
+	projectRepo := repository.InstrumentProjectRepository(repository.NewProjectRepository(database, cfg), observer)
+	taskHistoryRepo := repository.InstrumentTaskHistoryRepository(repository.NewTaskHistoryRepository(database, cfg), observer)
+	idempotencyRepo := repository.InstrumentIdempotencyRepository(repository.NewIdempotencyRepository(database, cfg), observer)
+	metrics.RegisterTaskStatus(registry, taskRepo)
+	// Probes are not instrumented, as they would crowd out the traces and timings of real requests
+	healthRepo := repository.NewHealthRepository(database)
 
 	// Initialize handlers
 	userHandler := handler.NewUserHandler(userRepo)
-	taskHandler := handler.NewTaskHandler(taskRepo)
+	taskHandler := handler.NewTaskHandler(taskRepo, projectRepo)
+	projectHandler := handler.NewProjectHandler(projectRepo)
+	taskHistoryHandler := handler.NewTaskHistoryHandler(taskHistoryRepo)
+	trashHandler := handler.NewTrashHandler(taskRepo, userRepo)
+	calendarHandler := handler.NewCalendarHandler(taskRepo, userRepo, cfg.CalendarSecret)
+	healthHandler := handler.NewHealthHandler(healthRepo, cfg.HealthCheckTimeout)
 
 	// Setup server
-	s := server.SetupServer(cfg, userHandler, taskHandler)
+	s := server.SetupServer(cfg, userHandler, taskHandler, projectHandler, taskHistoryHandler, trashHandler, calendarHandler, healthHandler, idempotencyRepo, ratelimit.NewMemoryStore(), registry, tracerProvider)
 
-	l, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
+	// Start background jobs
+	jobs := scheduler.New()
+	jobs.Add(scheduler.PurgeTrashJob(taskRepo, userRepo, cfg.TrashRetention, cfg.TrashPurgeInterval))
+	jobs.Add(scheduler.AutoArchiveJob(taskRepo, cfg.AutoArchiveDays, cfg.AutoArchiveInterval))
+	jobs.Add(scheduler.PurgeIdempotencyKeysJob(idempotencyRepo, cfg.IdempotencyTTL, cfg.IdempotencyPurgeInterval))
+
+	// Serve TLS when a certificate is configured, picking up renewed certificates as they are written
+	if cfg.TLSCertFile != "" {
+		reloader, err := tlsconfig.NewReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		if s.TLSConfig, err = tlsconfig.New(cfg, reloader); err != nil {
+			return err
+		}
+		jobs.Add(scheduler.ReloadCertificateJob(reloader, cfg.TLSReloadInterval))
+	}
+	jobs.Start(ctx)
+
+	l, err := net.Listen("tcp", s.Addr)
 	if err != nil {
-		log.Fatalf("failed to listen port %d: %v", cfg.Port, err)
+		return fmt.Errorf("failed to listen port %d: %w", cfg.Port, err)
 	}
 
-	url := fmt.Sprintf("http://%s", l.Addr().String())
-	log.Printf("start with: %v", url)
+	// Metrics are served on a port of their own, which is not meant to be reachable from outside
+	metricsServer := server.SetupMetricsServer(cfg, registry)
+	var ml net.Listener
+	if metricsServer != nil {
+		if ml, err = net.Listen("tcp", metricsServer.Addr); err != nil {
+			l.Close()
+			return fmt.Errorf("failed to listen metrics port %d: %w", cfg.MetricsPort, err)
+		}
+	}
+
+	scheme := "http"
+	if s.TLSConfig != nil {
+		scheme = "https"
+	}
+	url := fmt.Sprintf("%s://%s", scheme, l.Addr().String())
+	slog.Info("server started", slog.String("url", url))
 
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		if err := s.Serve(l); err != nil &&
-			err != http.ErrServerClosed {
-			log.Printf("failed to close: %+v", err)
+		var err error
+		if s.TLSConfig != nil {
+			// The certificate comes from TLSConfig.GetCertificate
+			err = s.ServeTLS(l, "", "")
+		} else {
+			err = s.Serve(l)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			slog.Error("failed to close", slog.Any("error", err))
 		}
 	}()
 
+	if metricsServer != nil {
+		slog.Info("metrics server started", slog.String("url", fmt.Sprintf("http://%s/metrics", ml.Addr().String())))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := metricsServer.Serve(ml); err != nil && err != http.ErrServerClosed {
+				slog.Error("failed to close metrics server", slog.Any("error", err))
+			}
+		}()
+	}
+
 	<-ctx.Done()
+	// A second signal stops the process without waiting for the drain
+	stop()
+
+	// Fail readiness first and keep serving while load balancers notice, so that no new request
+	// reaches a server that is closing its listener
+	healthHandler.ShutDown()
+	slog.Info("draining before shutdown", slog.Duration("delay", cfg.ShutdownDrainDelay))
+	time.Sleep(cfg.ShutdownDrainDelay)
+
 	if err := s.Shutdown(context.Background()); err != nil {
-		log.Printf("failed to shutdown: %+v", err)
+		slog.Error("failed to shutdown", slog.Any("error", err))
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(context.Background()); err != nil {
+			slog.Error("failed to shutdown metrics server", slog.Any("error", err))
+		}
 	}
 
 	wg.Wait()
+	jobs.Wait()
 	return nil
 }
+
+// newLogger builds the default logger from the configured format and level
+func newLogger(cfg *config.Config) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: cfg.LogLevel}
+	if cfg.LogFormat == "text" {
+		return slog.New(slog.NewTextHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+}
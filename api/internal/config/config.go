@@ -1,10 +1,16 @@
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
+	"log/slog"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -16,6 +22,85 @@ type Config struct {
 	DBUser      string
 	DBPass      string
 	CORSOrigins []string
+
+	// Prometheus metrics are served on MetricsPort, apart from the API so that they stay private (0 disables them)
+	MetricsPort int
+
+	// Trashed tasks and users older than TrashRetention are purged every TrashPurgeInterval (0 disables purging)
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	// Tasks Done for AutoArchiveDays are archived every AutoArchiveInterval (0 days disables auto-archiving)
+	AutoArchiveDays     int
+	AutoArchiveInterval time.Duration
+
+	// RequireIfMatch rejects PATCH and DELETE on tasks and users without an If-Match header
+	RequireIfMatch bool
+
+	// CacheControl maps GET route patterns such as /tasks/{id} to their Cache-Control header
+	CacheControl map[string]string
+
+	// Responses to POST requests with an Idempotency-Key header are kept for IdempotencyTTL (0 disables
+	// idempotency keys) and expired keys are purged every IdempotencyPurgeInterval
+	IdempotencyTTL           time.Duration
+	IdempotencyPurgeInterval time.Duration
+
+	// LogFormat selects the json or text log handler, and LogLevel the least severe level that is
+	// logged. At debug level every SQL statement is logged with its duration.
+	LogFormat string
+	LogLevel  slog.Level
+
+	// Spans are exported with OTLP over HTTP to TracingEndpoint (empty disables tracing), sending
+	// TracingHeaders with every export. TracingSampleRatio of the traces started here are recorded;
+	// traces continued from a caller follow the caller's decision.
+	TracingEndpoint    string
+	TracingHeaders     map[string]string
+	TracingServiceName string
+	TracingSampleRatio float64
+
+	// The server gives up on reading the request headers after ReadHeaderTimeout, the whole request
+	// after ReadTimeout and writing the response after WriteTimeout, and closes keep-alive connections
+	// idle for IdleTimeout. Handlers and the queries they run are cancelled after RequestTimeout, and
+	// request bodies are cut off after MaxBodySize bytes. A zero timeout disables it.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	RequestTimeout    time.Duration
+	MaxBodySize       int64
+
+	// Responses of at least CompressMinSize bytes are compressed for clients that accept it
+	CompressMinSize int64
+
+	// The server speaks TLS when TLSCertFile and TLSKeyFile are set, and loads them again whenever they
+	// change, checking every TLSReloadInterval. TLSClientAuth asks clients for certificates, which are
+	// verified against TLSClientCAFile. Without TLS, H2C serves HTTP/2 over plain connections.
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSMinVersion     uint16
+	TLSClientAuth     tls.ClientAuthType
+	TLSClientCAFile   string
+	TLSReloadInterval time.Duration
+	H2C               bool
+
+	// RateLimit is the number of requests each client may make per route, unless RateLimitRoutes has
+	// a limit for the route. Routes are keyed by pattern, optionally preceded by a method as in
+	// "GET /tasks". A zero limit leaves requests unlimited. X-Forwarded-For is only trusted on
+	// requests from TrustedProxies.
+	RateLimit       RateLimit
+	RateLimitRoutes map[string]RateLimit
+	TrustedProxies  []netip.Prefix
+
+	// Every readiness check of /readyz gives up after HealthCheckTimeout. On shutdown /readyz fails for
+	// ShutdownDrainDelay before the server stops accepting requests, so that load balancers drain it first.
+	HealthCheckTimeout time.Duration
+	ShutdownDrainDelay time.Duration
+
+	// Middleware names the server-wide middleware in the order it wraps the router, outermost first
+	Middleware []string
+
+	// CalendarSecret signs the tokens of the per-user calendar feeds (empty disables the feeds)
+	CalendarSecret string
 }
 
 func New() (*Config, error) {
@@ -29,6 +114,17 @@ func New() (*Config, error) {
 		return nil, fmt.Errorf("invalid port number: %w", err)
 	}
 
+	metricsPort := 0
+	if v := os.Getenv("METRICS_PORT"); v != "" {
+		metricsPort, err = strconv.Atoi(v)
+		if err != nil || metricsPort < 0 {
+			return nil, fmt.Errorf("invalid METRICS_PORT: %s", v)
+		}
+		if metricsPort == port {
+			return nil, fmt.Errorf("METRICS_PORT must differ from PORT, as metrics are not served with the API")
+		}
+	}
+
 	dbType := os.Getenv("DB_TYPE")
 	if dbType == "" {
 		return nil, fmt.Errorf("DB_TYPE is required")
@@ -82,14 +178,527 @@ func New() (*Config, error) {
 		return nil, fmt.Errorf("CORS_ORIGINS must contain at least one origin")
 	}
 
+	trashRetention, err := durationEnv("TRASH_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	trashPurgeInterval, err := durationEnv("TRASH_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	autoArchiveDays := 0
+	if v := os.Getenv("AUTO_ARCHIVE_DAYS"); v != "" {
+		autoArchiveDays, err = strconv.Atoi(v)
+		if err != nil || autoArchiveDays < 0 {
+			return nil, fmt.Errorf("invalid AUTO_ARCHIVE_DAYS: %s", v)
+		}
+	}
+
+	autoArchiveInterval, err := durationEnv("AUTO_ARCHIVE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	requireIfMatch := false
+	if v := os.Getenv("REQUIRE_IF_MATCH"); v != "" {
+		requireIfMatch, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REQUIRE_IF_MATCH: %s", v)
+		}
+	}
+
+	cacheControl, err := cacheControlEnv("CACHE_CONTROL")
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyTTL, err := durationEnv("IDEMPOTENCY_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyPurgeInterval, err := durationEnv("IDEMPOTENCY_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	logFormat := os.Getenv("LOG_FORMAT")
+	switch logFormat {
+	case "":
+		logFormat = "json"
+	case "json", "text":
+	default:
+		return nil, fmt.Errorf("invalid LOG_FORMAT: %s. expected json or text", logFormat)
+	}
+
+	logLevel := slog.LevelInfo
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if err := logLevel.UnmarshalText([]byte(v)); err != nil {
+			return nil, fmt.Errorf("invalid LOG_LEVEL: %s. expected debug, info, warn or error", v)
+		}
+	}
+
+	tracingEndpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if tracingEndpoint == "" {
+		if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			tracingEndpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+
+	tracingHeaders, err := otlpHeadersEnv("OTEL_EXPORTER_OTLP_HEADERS")
+	if err != nil {
+		return nil, err
+	}
+
+	tracingServiceName := os.Getenv("OTEL_SERVICE_NAME")
+	if tracingServiceName == "" {
+		tracingServiceName = "todo-api-go"
+	}
+
+	tracingSampleRatio := 1.0
+	if v := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); v != "" {
+		tracingSampleRatio, err = strconv.ParseFloat(v, 64)
+		if err != nil || tracingSampleRatio < 0 || tracingSampleRatio > 1 {
+			return nil, fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG: %s. expected a ratio between 0 and 1", v)
+		}
+	}
+
+	readHeaderTimeout, err := durationEnv("READ_HEADER_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	readTimeout, err := durationEnv("READ_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	writeTimeout, err := durationEnv("WRITE_TIMEOUT", 60*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	idleTimeout, err := durationEnv("IDLE_TIMEOUT", 120*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	requestTimeout, err := durationEnv("REQUEST_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	// A handler still running when the write timeout hits can no longer answer
+	if writeTimeout > 0 && (requestTimeout == 0 || requestTimeout >= writeTimeout) {
+		return nil, fmt.Errorf("REQUEST_TIMEOUT must be shorter than WRITE_TIMEOUT")
+	}
+
+	maxBodySize, err := byteSizeEnv("MAX_BODY_SIZE", 1<<20)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsCertFile, tlsKeyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	if (tlsCertFile == "") != (tlsKeyFile == "") {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	tlsMinVersion := uint16(tls.VersionTLS12)
+	switch v := os.Getenv("TLS_MIN_VERSION"); v {
+	case "", "1.2":
+	case "1.3":
+		tlsMinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("invalid TLS_MIN_VERSION: %s. expected 1.2 or 1.3", v)
+	}
+
+	tlsClientAuth := tls.NoClientCert
+	if v := os.Getenv("TLS_CLIENT_AUTH"); v != "" {
+		var ok bool
+		if tlsClientAuth, ok = clientAuthTypes[v]; !ok {
+			return nil, fmt.Errorf("invalid TLS_CLIENT_AUTH: %s. expected none, request, require, verify_if_given or require_and_verify", v)
+		}
+	}
+
+	tlsClientCAFile := os.Getenv("TLS_CLIENT_CA_FILE")
+	if tlsClientAuth >= tls.VerifyClientCertIfGiven && tlsClientCAFile == "" {
+		return nil, fmt.Errorf("TLS_CLIENT_CA_FILE is required to verify client certificates")
+	}
+	if tlsClientAuth != tls.NoClientCert && tlsCertFile == "" {
+		return nil, fmt.Errorf("TLS_CLIENT_AUTH requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+
+	tlsReloadInterval, err := durationEnv("TLS_RELOAD_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	h2c := false
+	if v := os.Getenv("H2C"); v != "" {
+		h2c, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid H2C: %s", v)
+		}
+	}
+	if h2c && tlsCertFile != "" {
+		return nil, fmt.Errorf("H2C cannot be combined with TLS, which negotiates HTTP/2 on its own")
+	}
+
+	compressMinSize, err := byteSizeEnv("COMPRESS_MIN_SIZE", 1<<10)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimit := RateLimit{Requests: 300, Period: time.Minute}
+	if v := os.Getenv("RATE_LIMIT"); v != "" {
+		rateLimit, err = ParseRateLimit(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT: %w", err)
+		}
+	}
+
+	rateLimitRoutes, err := rateLimitRoutesEnv("RATE_LIMIT_ROUTES")
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies, err := trustedProxiesEnv("TRUSTED_PROXIES")
+	if err != nil {
+		return nil, err
+	}
+
+	healthCheckTimeout, err := durationEnv("HEALTH_CHECK_TIMEOUT", 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if healthCheckTimeout == 0 {
+		return nil, fmt.Errorf("HEALTH_CHECK_TIMEOUT must be positive")
+	}
+
+	shutdownDrainDelay, err := durationEnv("SHUTDOWN_DRAIN_DELAY", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	middleware, err := middlewareEnv("MIDDLEWARE")
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
-		Port:        port,
-		DBType:      dbType,
-		DBHost:      dbHost,
-		DBPort:      dbPort,
-		DBName:      dbName,
-		DBUser:      dbUser,
-		DBPass:      dbPass,
-		CORSOrigins: corsOrigins,
+		Port:                     port,
+		MetricsPort:              metricsPort,
+		DBType:                   dbType,
+		DBHost:                   dbHost,
+		DBPort:                   dbPort,
+		DBName:                   dbName,
+		DBUser:                   dbUser,
+		DBPass:                   dbPass,
+		CORSOrigins:              corsOrigins,
+		TrashRetention:           trashRetention,
+		TrashPurgeInterval:       trashPurgeInterval,
+		AutoArchiveDays:          autoArchiveDays,
+		AutoArchiveInterval:      autoArchiveInterval,
+		RequireIfMatch:           requireIfMatch,
+		CacheControl:             cacheControl,
+		IdempotencyTTL:           idempotencyTTL,
+		IdempotencyPurgeInterval: idempotencyPurgeInterval,
+		LogFormat:                logFormat,
+		LogLevel:                 logLevel,
+		TracingEndpoint:          tracingEndpoint,
+		TracingHeaders:           tracingHeaders,
+		TracingServiceName:       tracingServiceName,
+		TracingSampleRatio:       tracingSampleRatio,
+		ReadHeaderTimeout:        readHeaderTimeout,
+		ReadTimeout:              readTimeout,
+		WriteTimeout:             writeTimeout,
+		IdleTimeout:              idleTimeout,
+		RequestTimeout:           requestTimeout,
+		MaxBodySize:              maxBodySize,
+		CompressMinSize:          compressMinSize,
+		TLSCertFile:              tlsCertFile,
+		TLSKeyFile:               tlsKeyFile,
+		TLSMinVersion:            tlsMinVersion,
+		TLSClientAuth:            tlsClientAuth,
+		TLSClientCAFile:          tlsClientCAFile,
+		TLSReloadInterval:        tlsReloadInterval,
+		H2C:                      h2c,
+		RateLimit:                rateLimit,
+		RateLimitRoutes:          rateLimitRoutes,
+		TrustedProxies:           trustedProxies,
+		HealthCheckTimeout:       healthCheckTimeout,
+		ShutdownDrainDelay:       shutdownDrainDelay,
+		Middleware:               middleware,
+		CalendarSecret:           os.Getenv("CALENDAR_SECRET"),
 	}, nil
 }
+
+// durationEnv reads a duration such as "720h" from the environment, falling back to def when unset
+func durationEnv(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("%s must not be negative", key)
+	}
+	return d, nil
+}
+
+// byteSizes are the units accepted by byteSizeEnv, longest first so that "MiB" is not read as "B"
+var byteSizes = []struct {
+	suffix string
+	size   int64
+}{
+	{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+	{"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3}, {"B", 1},
+}
+
+// byteSizeEnv reads a positive size such as "1MiB", "512KB" or "1048576" from the environment,
+// falling back to def when unset
+func byteSizeEnv(key string, def int64) (int64, error) {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return def, nil
+	}
+
+	number, unit := v, int64(1)
+	for _, s := range byteSizes {
+		if n, ok := strings.CutSuffix(v, s.suffix); ok {
+			number, unit = strings.TrimSpace(n), s.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s: %s. expected a positive size such as 1MiB", key, v)
+	}
+	return n * unit, nil
+}
+
+// DefaultMiddleware lists every server-wide middleware in its default order. Request IDs and traces
+// come first so that every log line can name them, and Recover sits inside AccessLog and Metrics so that
+// the 500 it answers a panic with is logged and counted. Compress sits inside Recover so that a panic
+// discards the response it has buffered. The body limit has to wrap Idempotency, which reads the
+// whole body. Rate limiting needs the actor and runs before Idempotency, so that a rejected request
+// does not reserve a key.
+var DefaultMiddleware = []string{"request_id", "tracing", "access_log", "metrics", "recover", "compress", "security_headers", "cors", "body_limit", "timeout", "actor", "rate_limit", "idempotency", "cache_control"}
+
+// middlewareEnv reads the middleware order such as "request_id,recover,cors,actor" from the
+// environment. Middleware left out of the list is disabled.
+func middlewareEnv(key string) ([]string, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return DefaultMiddleware, nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(DefaultMiddleware, name) {
+			return nil, fmt.Errorf("unknown %s entry: %q", key, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate %s entry: %q", key, name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// otlpHeadersEnv reads headers such as "authorization=Bearer%20token,x-tenant=todo" in the format
+// OpenTelemetry uses, with percent-encoded values
+func otlpHeadersEnv(key string) (map[string]string, error) {
+	headers := make(map[string]string)
+	v := os.Getenv(key)
+	if v == "" {
+		return headers, nil
+	}
+
+	for _, entry := range strings.Split(v, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid %s entry: %q", key, entry)
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry: %q", key, entry)
+		}
+		headers[name] = value
+	}
+	return headers, nil
+}
+
+// clientAuthTypes names the ways the server can ask clients for certificates, from none to mutual TLS
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// RateLimit allows a burst of Requests, refilled evenly over Period. The zero value is unlimited.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Unlimited reports whether the limit lets every request through
+func (l RateLimit) Unlimited() bool {
+	return l.Requests == 0
+}
+
+// ParseRateLimit reads a limit such as "100/1m", or "0" for no limit
+func ParseRateLimit(v string) (RateLimit, error) {
+	if strings.TrimSpace(v) == "0" {
+		return RateLimit{}, nil
+	}
+
+	requests, period, ok := strings.Cut(v, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("%q: expected requests/period, such as 100/1m", v)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n <= 0 {
+		return RateLimit{}, fmt.Errorf("%q: expected a positive number of requests", v)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("%q: expected a positive period", v)
+	}
+	return RateLimit{Requests: n, Period: d}, nil
+}
+
+// defaultRateLimitRoutes leaves the probes of orchestrators and load balancers unlimited, as they
+// all come from a handful of addresses
+var defaultRateLimitRoutes = map[string]RateLimit{
+	"/healthz": {},
+	"/readyz":  {},
+}
+
+// rateLimitRoutesEnv reads per-route limits such as "GET /tasks=20/1s;/tasks/import=5/1m" on top
+// of the defaults. A limit of 0 leaves a route unlimited, and an empty value falls back to RATE_LIMIT.
+func rateLimitRoutesEnv(key string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit, len(defaultRateLimitRoutes))
+	for route, limit := range defaultRateLimitRoutes {
+		limits[route] = limit
+	}
+
+	v := os.Getenv(key)
+	if v == "" {
+		return limits, nil
+	}
+
+	for _, entry := range strings.Split(v, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(entry, "=")
+		route = strings.Join(strings.Fields(route), " ")
+		pattern := route
+		if method, rest, ok := strings.Cut(route, " "); ok && strings.ToUpper(method) == method {
+			pattern = rest
+		}
+		if !ok || !strings.HasPrefix(pattern, "/") {
+			return nil, fmt.Errorf("invalid %s entry: %q", key, entry)
+		}
+		if strings.TrimSpace(value) == "" {
+			delete(limits, route)
+			continue
+		}
+		limit, err := ParseRateLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry: %w", key, err)
+		}
+		limits[route] = limit
+	}
+	return limits, nil
+}
+
+// trustedProxiesEnv reads the addresses of trusted proxies such as "10.0.0.0/8,192.168.1.10"
+func trustedProxiesEnv(key string) ([]netip.Prefix, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil, nil
+	}
+
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s entry: %q", key, entry)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry: %q", key, entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// defaultCacheControl makes clients revalidate polled resources on every request, which is cheap
+// now that they answer conditional requests with 304
+var defaultCacheControl = map[string]string{
+	"/tasks":                "private, no-cache",
+	"/tasks/{id}":           "private, no-cache",
+	"/users":                "private, no-cache",
+	"/users/{id}":           "private, no-cache",
+	"/users/{id}/tasks":     "private, no-cache",
+	"/users/{id}/tasks.ics": "private, no-cache",
+}
+
+// cacheControlEnv reads per-route Cache-Control overrides such as
+// "/tasks=private, max-age=10;/users=no-store" on top of the defaults. An empty value removes a route.
+func cacheControlEnv(key string) (map[string]string, error) {
+	rules := make(map[string]string, len(defaultCacheControl))
+	for route, value := range defaultCacheControl {
+		rules[route] = value
+	}
+
+	v := os.Getenv(key)
+	if v == "" {
+		return rules, nil
+	}
+
+	for _, entry := range strings.Split(v, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(entry, "=")
+		route = strings.TrimSpace(route)
+		if !ok || !strings.HasPrefix(route, "/") {
+			return nil, fmt.Errorf("invalid %s entry: %q", key, entry)
+		}
+		if value = strings.TrimSpace(value); value == "" {
+			delete(rules, route)
+		} else {
+			rules[route] = value
+		}
+	}
+	return rules, nil
+}
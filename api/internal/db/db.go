@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"os"
 
@@ -32,5 +33,20 @@ func NewDB() (*sql.DB, error) {
 		return nil, fmt.Errorf("unsupported database type: %s", dbType)
 	}
 
-	return sql.Open(driverName, dsn)
+	// Opening does not connect, it only looks up the registered driver to wrap
+	base, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := base.Driver()
+	base.Close()
+
+	var connector driver.Connector = dsnConnector{dsn: dsn, driver: drv}
+	if driverContext, ok := drv.(driver.DriverContext); ok {
+		connector, err = driverContext.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sql.OpenDB(loggingConnector{Connector: connector}), nil
 }
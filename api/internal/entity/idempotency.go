@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// IdempotencyRecord is the stored outcome of a request sent with an Idempotency-Key header.
+// StatusCode stays zero while the original request is still being processed.
+type IdempotencyRecord struct {
+	Actor       string
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	ContentType string
+	ETag        string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Completed reports whether the original request has finished and its response can be replayed
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -10,6 +10,9 @@ const (
 	TaskStatusDone  TaskStatus = "Done"
 )
 
+// TaskStatuses lists every status a task can have
+var TaskStatuses = []TaskStatus{TaskStatusTodo, TaskStatusDoing, TaskStatusDone}
+
 type Task struct {
 	ID          int64      `json:"id"`
 	Title       string     `json:"title"`
@@ -17,6 +20,10 @@ type Task struct {
 	DueDate     string     `json:"due_date"`
 	Status      TaskStatus `json:"status"`
 	OwnerID     int64      `json:"owner_id"`
+	ProjectID   *int64     `json:"project_id"`
+	Archived    bool       `json:"archived"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+	Version     int64      `json:"version"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
 }
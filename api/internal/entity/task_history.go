@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+type TaskHistoryAction string
+
+const (
+	TaskHistoryActionCreate  TaskHistoryAction = "create"
+	TaskHistoryActionUpdate  TaskHistoryAction = "update"
+	TaskHistoryActionDelete  TaskHistoryAction = "delete"
+	TaskHistoryActionRestore TaskHistoryAction = "restore"
+)
+
+// TaskHistory is a single field-level change made to a task
+type TaskHistory struct {
+	ID        int64             `json:"id"`
+	TaskID    int64             `json:"task_id"`
+	Action    TaskHistoryAction `json:"action"`
+	Field     string            `json:"field"`
+	OldValue  *string           `json:"old_value"`
+	NewValue  *string           `json:"new_value"`
+	Actor     string            `json:"actor"`
+	CreatedAt time.Time         `json:"created_at"`
+}
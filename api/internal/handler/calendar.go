@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
+	"github.com/kenwoo9y/todo-api-go/api/internal/taskio"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+type CalendarHandler struct {
+	taskRepo repository.TaskRepository
+	userRepo repository.UserRepository
+	secret   []byte
+	mux      *router.Router
+}
+
+// NewCalendarHandler creates the handler of the per-user calendar feeds. The feeds are disabled
+// when secret is empty.
+func NewCalendarHandler(taskRepo repository.TaskRepository, userRepo repository.UserRepository, secret string) *CalendarHandler {
+	h := &CalendarHandler{taskRepo: taskRepo, userRepo: userRepo, secret: []byte(secret)}
+	h.mux = router.New(h.Routes()...)
+	return h
+}
+
+// Routes lists the endpoints served by the handler
+func (h *CalendarHandler) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Pattern: "/users/{id}/tasks.ics", Handler: h.GetFeed},
+		{Method: http.MethodHead, Pattern: "/users/{id}/tasks.ics", Handler: h.GetFeed},
+	}
+}
+
+// ServeHTTP serves the routes of the handler on their own
+func (h *CalendarHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// GetFeed renders the active tasks of a user as an iCalendar feed. Calendar clients cannot send
+// custom headers, so the feed is authorized by the token in the query string instead of X-User-ID.
+// Pass component=vevent for clients that only display events.
+func (h *CalendarHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+
+	if len(h.secret) == 0 {
+		common.HandleError(w, common.ErrNotFound)
+		return
+	}
+
+	userID, err := common.PathID(r, "id")
+	if err != nil {
+		common.HandleError(w, common.ErrInvalidID)
+		return
+	}
+
+	if !h.validToken(userID, r.URL.Query().Get("token")) {
+		common.HandleError(w, common.ErrForbidden)
+		return
+	}
+
+	component, err := taskio.ParseICalComponent(r.URL.Query().Get("component"))
+	if err != nil {
+		common.ErrorJSONResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), userID)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	if user == nil {
+		common.HandleError(w, common.ErrNotFound)
+		return
+	}
+
+	tasks, err := h.taskRepo.GetByOwnerID(r.Context(), userID, repository.TaskListOptions{})
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	var body bytes.Buffer
+	if err := taskio.WriteICalendar(&body, user.Username+" tasks", component, tasks); err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	// Only the ETag validates the feed, as removing a task leaves the update times of the rest alone
+	if common.CheckNotModified(w, r, common.ETag(body.Bytes()), time.Time{}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", taskio.ICalContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="tasks-%d.ics"`, userID))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body.Bytes())
+	}
+}
+
+// CalendarFeedURL returns the token-protected feed path of a user. The API has no authentication to
+// hand it out with, so it is issued out of band by an operator holding the secret.
+func CalendarFeedURL(secret string, userID int64) string {
+	return fmt.Sprintf("/users/%d/tasks.ics?token=%s", userID, calendarToken([]byte(secret), userID))
+}
+
+// calendarToken signs the user ID so that feed URLs cannot be guessed for other users
+func calendarToken(secret []byte, userID int64) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("calendar:" + strconv.FormatInt(userID, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (h *CalendarHandler) validToken(userID int64, token string) bool {
+	return token != "" && hmac.Equal([]byte(token), []byte(calendarToken(h.secret, userID)))
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+func newTestCalendarHandler(secret string) *CalendarHandler {
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	taskRepo := &MockTaskRepository{
+		getByOwnerIDFunc: func(ctx context.Context, ownerID int64, opts repository.TaskListOptions) ([]entity.Task, error) {
+			return []entity.Task{
+				{ID: 1, Title: "タスク1", DueDate: "2024-01-10", Status: entity.TaskStatusTodo, OwnerID: ownerID, UpdatedAt: updatedAt},
+				{ID: 2, Title: "タスク2", DueDate: "2024-01-11", Status: entity.TaskStatusDone, OwnerID: ownerID, UpdatedAt: updatedAt},
+			}, nil
+		},
+	}
+	userRepo := &MockUserRepository{
+		getByIDFunc: func(ctx context.Context, id int64) (*entity.User, error) {
+			if id != 1 {
+				return nil, nil
+			}
+			return &entity.User{ID: 1, Username: "testuser"}, nil
+		},
+	}
+	return NewCalendarHandler(taskRepo, userRepo, secret)
+}
+
+func TestCalendarHandler_GetFeed(t *testing.T) {
+	handler := newTestCalendarHandler("secret")
+	token := calendarToken([]byte("secret"), 1)
+
+	tests := []struct {
+		name           string
+		url            string
+		headers        map[string]string
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:           "Success: VTODO feed",
+			url:            "/users/1/tasks.ics?token=" + token,
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"BEGIN:VTODO\r\n", "UID:task-1@todo-api-go\r\n", "STATUS:NEEDS-ACTION\r\n", "STATUS:COMPLETED\r\n", "DUE;VALUE=DATE:20240110\r\n"},
+		},
+		{
+			name:           "Success: VEVENT feed",
+			url:            "/users/1/tasks.ics?component=vevent&token=" + token,
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"BEGIN:VEVENT\r\n", "DTSTART;VALUE=DATE:20240110\r\n", "DTEND;VALUE=DATE:20240111\r\n"},
+		},
+		{
+			name:           "Success: If-Modified-Since is ignored, as removed tasks do not move it",
+			url:            "/users/1/tasks.ics?token=" + token,
+			headers:        map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Error: Invalid token",
+			url:            "/users/1/tasks.ics?token=invalid",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Error: Token of another user",
+			url:            "/users/2/tasks.ics?token=" + token,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Error: Unsupported component",
+			url:            "/users/1/tasks.ics?component=vjournal&token=" + token,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			for _, expected := range tt.expectedBody {
+				if !strings.Contains(w.Body.String(), expected) {
+					t.Errorf("expected body to contain %q, got %q", expected, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestCalendarHandler_GetFeed_ETag(t *testing.T) {
+	handler := newTestCalendarHandler("secret")
+	url := CalendarFeedURL("secret", 1)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	etag := w.Header().Get("ETag")
+	if etag == "" || w.Header().Get("Last-Modified") != "" {
+		t.Fatalf("expected only an ETag, got ETag %q Last-Modified %q", etag, w.Header().Get("Last-Modified"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected 304 without a body, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+}
+
+func TestCalendarHandler_NoSelfServiceFeedURL(t *testing.T) {
+	handler := newTestCalendarHandler("secret")
+	req := httptest.NewRequest(http.MethodGet, "/users/1/calendar", nil)
+	// X-User-ID is not authenticated, so it must not be enough to obtain a feed token
+	req = req.WithContext(common.WithActor(req.Context(), "1"))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+// Health statuses of the process and of the components it depends on
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// HealthResponse is the body of the health endpoints. Checks is only set by /readyz.
+type HealthResponse struct {
+	Status string                     `json:"status"`
+	Checks map[string]ComponentHealth `json:"checks,omitempty"`
+}
+
+// ComponentHealth only carries the status, as the probes are unauthenticated. Why a check failed is
+// logged instead.
+type ComponentHealth struct {
+	Status string `json:"status"`
+}
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	healthRepo   repository.HealthRepository
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+	mux          *router.Router
+}
+
+// NewHealthHandler creates a handler whose readiness checks each give up after timeout
+func NewHealthHandler(healthRepo repository.HealthRepository, timeout time.Duration) *HealthHandler {
+	h := &HealthHandler{healthRepo: healthRepo, timeout: timeout}
+	h.mux = router.New(h.Routes()...)
+	return h
+}
+
+// Routes lists the endpoints served by the handler
+func (h *HealthHandler) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Pattern: "/healthz", Handler: h.Live},
+		{Method: http.MethodGet, Pattern: "/readyz", Handler: h.Ready},
+	}
+}
+
+// ServeHTTP serves the routes of the handler on their own
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// ShutDown makes the readiness probe fail from now on, so that load balancers stop sending new
+// requests before the server shuts down
+func (h *HealthHandler) ShutDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Live reports that the process is up and serving requests. It checks nothing else, so that a
+// database outage does not get the process restarted.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	common.JSONResponse(w, r, http.StatusOK, HealthResponse{Status: HealthStatusOK})
+}
+
+// Ready reports whether the process can serve traffic: the database answers, its schema is applied
+// and the server is not shutting down. Every check is reported, and any failing one answers 503.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	resp := HealthResponse{
+		Status: HealthStatusOK,
+		Checks: map[string]ComponentHealth{
+			"database":   h.check(r.Context(), "database", h.healthRepo.Ping),
+			"migrations": h.check(r.Context(), "migrations", h.healthRepo.CheckSchema),
+			"shutdown":   {Status: HealthStatusOK},
+		},
+	}
+	if h.shuttingDown.Load() {
+		resp.Checks["shutdown"] = ComponentHealth{Status: HealthStatusUnavailable}
+	}
+
+	status := http.StatusOK
+	for _, check := range resp.Checks {
+		if check.Status != HealthStatusOK {
+			resp.Status = HealthStatusUnavailable
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	common.JSONResponse(w, r, status, resp)
+}
+
+func (h *HealthHandler) check(ctx context.Context, name string, fn func(ctx context.Context) error) ComponentHealth {
+	checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	if err := fn(checkCtx); err != nil {
+		common.Logger(ctx).Error("readiness check failed", slog.String("check", name), slog.Any("error", err))
+		return ComponentHealth{Status: HealthStatusUnavailable}
+	}
+	return ComponentHealth{Status: HealthStatusOK}
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+// MockHealthRepository is a mock implementation of repository.HealthRepository
+type MockHealthRepository struct {
+	pingFunc        func(ctx context.Context) error
+	checkSchemaFunc func(ctx context.Context) error
+}
+
+func (m *MockHealthRepository) Ping(ctx context.Context) error {
+	return m.pingFunc(ctx)
+}
+
+func (m *MockHealthRepository) CheckSchema(ctx context.Context) error {
+	return m.checkSchemaFunc(ctx)
+}
+
+func TestHealthHandler_Live(t *testing.T) {
+	mockRepo := &MockHealthRepository{
+		pingFunc: func(ctx context.Context) error {
+			t.Error("expected the liveness probe not to check the database")
+			return nil
+		},
+	}
+	handler := NewHealthHandler(mockRepo, time.Second)
+	handler.ShutDown()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var resp HealthResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Status != HealthStatusOK || resp.Checks != nil {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestHealthHandler_Ready(t *testing.T) {
+	tests := []struct {
+		name           string
+		pingErr        error
+		schemaErr      error
+		slowPing       bool
+		shuttingDown   bool
+		expectedStatus int
+		expectedChecks map[string]string
+	}{
+		{
+			name:           "Success: Ready",
+			expectedStatus: http.StatusOK,
+			expectedChecks: map[string]string{"database": HealthStatusOK, "migrations": HealthStatusOK, "shutdown": HealthStatusOK},
+		},
+		{
+			name:           "Error: Database unreachable",
+			pingErr:        errors.New("connection refused"),
+			schemaErr:      errors.New("connection refused"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"database": HealthStatusUnavailable, "migrations": HealthStatusUnavailable, "shutdown": HealthStatusOK},
+		},
+		{
+			name:           "Error: Database too slow",
+			slowPing:       true,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"database": HealthStatusUnavailable, "migrations": HealthStatusOK, "shutdown": HealthStatusOK},
+		},
+		{
+			name:           "Error: Migrations not applied",
+			schemaErr:      errors.New("table tasks is missing or out of date"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"database": HealthStatusOK, "migrations": HealthStatusUnavailable, "shutdown": HealthStatusOK},
+		},
+		{
+			name:           "Error: Shutting down",
+			shuttingDown:   true,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedChecks: map[string]string{"database": HealthStatusOK, "migrations": HealthStatusOK, "shutdown": HealthStatusUnavailable},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockHealthRepository{
+				pingFunc: func(ctx context.Context) error {
+					if tt.slowPing {
+						<-ctx.Done()
+						return ctx.Err()
+					}
+					return tt.pingErr
+				},
+				checkSchemaFunc: func(ctx context.Context) error {
+					return tt.schemaErr
+				},
+			}
+			handler := NewHealthHandler(mockRepo, 10*time.Millisecond)
+			if tt.shuttingDown {
+				handler.ShutDown()
+			}
+
+			var logs bytes.Buffer
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			req = req.WithContext(common.WithLogger(req.Context(), slog.New(slog.NewJSONHandler(&logs, nil))))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Header().Get("Cache-Control") != "no-store" {
+				t.Errorf("expected probes not to be cached, got %q", w.Header().Get("Cache-Control"))
+			}
+
+			body := w.Body.String()
+			var resp HealthResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			expectedStatus := HealthStatusOK
+			if tt.expectedStatus != http.StatusOK {
+				expectedStatus = HealthStatusUnavailable
+			}
+			if resp.Status != expectedStatus {
+				t.Errorf("expected status %q, got %q", expectedStatus, resp.Status)
+			}
+			for name, expected := range tt.expectedChecks {
+				check := resp.Checks[name]
+				if check.Status != expected {
+					t.Errorf("expected %s to be %q, got %q", name, expected, check.Status)
+				}
+			}
+			for _, err := range []error{tt.pingErr, tt.schemaErr} {
+				if err == nil {
+					continue
+				}
+				if strings.Contains(body, err.Error()) {
+					t.Errorf("expected the error not to be exposed, got %s", body)
+				}
+				if !strings.Contains(logs.String(), err.Error()) {
+					t.Errorf("expected the error to be logged, got %s", logs.String())
+				}
+			}
+		})
+	}
+}
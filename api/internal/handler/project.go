@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+const defaultProjectColor = "#808080"
+
+type ProjectHandler struct {
+	repo repository.ProjectRepository
+	mux  *router.Router
+}
+
+func NewProjectHandler(repo repository.ProjectRepository) *ProjectHandler {
+	h := &ProjectHandler{repo: repo}
+	h.mux = router.New(h.Routes()...)
+	return h
+}
+
+type CreateProjectRequest struct {
+	Name        string `json:"name" validate:"required,max=30"`
+	Description string `json:"description"`
+	Color       string `json:"color" validate:"hexcolor"`
+}
+
+type UpdateProjectRequest struct {
+	Name        *string `json:"name,omitempty" validate:"required,max=30"`
+	Description *string `json:"description,omitempty"`
+	Color       *string `json:"color,omitempty" validate:"required,hexcolor"`
+	Archived    *bool   `json:"archived,omitempty"`
+}
+
+// Routes lists the endpoints served by the handler
+func (h *ProjectHandler) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodPost, Pattern: "/projects", Handler: h.Create},
+		{Method: http.MethodGet, Pattern: "/projects", Handler: h.GetAll},
+		{Method: http.MethodGet, Pattern: "/projects/{id}", Handler: h.GetByID},
+		{Method: http.MethodPatch, Pattern: "/projects/{id}", Handler: h.Update},
+		{Method: http.MethodDelete, Pattern: "/projects/{id}", Handler: h.Delete},
+	}
+}
+
+// ServeHTTP serves the routes of the handler on their own
+func (h *ProjectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *ProjectHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req CreateProjectRequest
+	if err := common.DecodeJSON(r, &req); err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	if req.Color == "" {
+		req.Color = defaultProjectColor
+	}
+
+	project := &entity.Project{
+		Name:        req.Name,
+		Description: req.Description,
+		Color:       req.Color,
+	}
+
+	if err := h.repo.Create(r.Context(), project); err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	common.JSONResponse(w, r, http.StatusCreated, project)
+}
+
+func (h *ProjectHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	projects, err := h.repo.GetAll(r.Context())
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	common.JSONResponse(w, r, http.StatusOK, projects)
+}
+
+func (h *ProjectHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	id, err := common.PathID(r, "id")
+	if err != nil {
+		common.HandleError(w, common.ErrInvalidID)
+		return
+	}
+
+	project, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	if project == nil {
+		common.HandleError(w, common.ErrNotFound)
+		return
+	}
+
+	common.JSONResponse(w, r, http.StatusOK, project)
+}
+
+func (h *ProjectHandler) Update(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodPatch) {
+		return
+	}
+
+	id, err := common.PathID(r, "id")
+	if err != nil {
+		common.HandleError(w, common.ErrInvalidID)
+		return
+	}
+
+	existingProject, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	if existingProject == nil {
+		common.HandleError(w, common.ErrNotFound)
+		return
+	}
+
+	var req UpdateProjectRequest
+	if err := common.DecodeJSON(r, &req); err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	if req.Name == nil && req.Description == nil && req.Color == nil && req.Archived == nil {
+		common.HandleError(w, common.ErrNoUpdateFields)
+		return
+	}
+
+	if req.Name != nil {
+		existingProject.Name = *req.Name
+	}
+	if req.Description != nil {
+		existingProject.Description = *req.Description
+	}
+	if req.Color != nil {
+		existingProject.Color = *req.Color
+	}
+	if req.Archived != nil {
+		existingProject.Archived = *req.Archived
+	}
+
+	if err := h.repo.Update(r.Context(), existingProject); err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	common.JSONResponse(w, r, http.StatusOK, existingProject)
+}
+
+// Delete removes a project. The mode query parameter selects how its tasks are handled:
+// archive (default) keeps everything and archives the project, cascade deletes the tasks,
+// and reassign moves them to the project given by target_project_id.
+func (h *ProjectHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodDelete) {
+		return
+	}
+
+	id, err := common.PathID(r, "id")
+	if err != nil {
+		common.HandleError(w, common.ErrInvalidID)
+		return
+	}
+
+	mode := repository.ProjectDeleteMode(r.URL.Query().Get("mode"))
+	if mode == "" {
+		mode = repository.ProjectDeleteArchive
+	}
+
+	var targetID int64
+	switch mode {
+	case repository.ProjectDeleteArchive, repository.ProjectDeleteCascade:
+	case repository.ProjectDeleteReassign:
+		targetID, err = strconv.ParseInt(r.URL.Query().Get("target_project_id"), 10, 64)
+		if err != nil || targetID == id {
+			common.HandleError(w, common.ErrInvalidProjectID)
+			return
+		}
+		target, err := h.repo.GetByID(r.Context(), targetID)
+		if err != nil {
+			common.HandleError(w, err)
+			return
+		}
+		if target == nil {
+			common.HandleError(w, common.ErrInvalidProjectID)
+			return
+		}
+	default:
+		common.HandleError(w, common.NewValidationError(common.FieldError{Field: "mode", Code: "invalid_value", Message: "invalid mode. expected one of: archive, cascade, reassign"}))
+		return
+	}
+
+	project, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	if project == nil {
+		common.HandleError(w, common.ErrNotFound)
+		return
+	}
+
+	if err := h.repo.Delete(r.Context(), id, mode, targetID); err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	common.JSONResponse(w, r, http.StatusNoContent, nil)
+}
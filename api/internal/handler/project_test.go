@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+)
+
+// MockProjectRepository is a mock implementation of repository.ProjectRepository
+type MockProjectRepository struct {
+	createFunc  func(ctx context.Context, project *entity.Project) error
+	getAllFunc  func(ctx context.Context) ([]entity.Project, error)
+	getByIDFunc func(ctx context.Context, id int64) (*entity.Project, error)
+	updateFunc  func(ctx context.Context, project *entity.Project) error
+	deleteFunc  func(ctx context.Context, id int64, mode repository.ProjectDeleteMode, targetID int64) error
+}
+
+func (m *MockProjectRepository) Create(ctx context.Context, project *entity.Project) error {
+	return m.createFunc(ctx, project)
+}
+
+func (m *MockProjectRepository) GetAll(ctx context.Context) ([]entity.Project, error) {
+	return m.getAllFunc(ctx)
+}
+
+func (m *MockProjectRepository) GetByID(ctx context.Context, id int64) (*entity.Project, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *MockProjectRepository) Update(ctx context.Context, project *entity.Project) error {
+	return m.updateFunc(ctx, project)
+}
+
+func (m *MockProjectRepository) Delete(ctx context.Context, id int64, mode repository.ProjectDeleteMode, targetID int64) error {
+	return m.deleteFunc(ctx, id, mode, targetID)
+}
+
+func TestProjectHandler_Create(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    CreateProjectRequest
+		expectedStatus int
+		expectedColor  string
+	}{
+		{
+			name: "Success: Project creation succeeds",
+			requestBody: CreateProjectRequest{
+				Name:        "仕事",
+				Description: "仕事のタスク",
+				Color:       "#FF8800",
+			},
+			expectedStatus: http.StatusCreated,
+			expectedColor:  "#FF8800",
+		},
+		{
+			name:           "Success: Color defaults when omitted",
+			requestBody:    CreateProjectRequest{Name: "仕事"},
+			expectedStatus: http.StatusCreated,
+			expectedColor:  defaultProjectColor,
+		},
+		{
+			name:           "Error: Name is empty",
+			requestBody:    CreateProjectRequest{Color: "#FF8800"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Error: Invalid color",
+			requestBody:    CreateProjectRequest{Name: "仕事", Color: "orange"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockProjectRepository{
+				createFunc: func(ctx context.Context, project *entity.Project) error {
+					project.ID = 1
+					return nil
+				},
+			}
+
+			handler := NewProjectHandler(mockRepo)
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/projects", bytes.NewBuffer(body))
+			w := httptest.NewRecorder()
+
+			handler.Create(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusCreated {
+				var response entity.Project
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Errorf("failed to decode response: %v", err)
+				}
+				if response.Color != tt.expectedColor {
+					t.Errorf("expected color %s, got %s", tt.expectedColor, response.Color)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectHandler_Delete(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedMode   repository.ProjectDeleteMode
+		expectedTarget int64
+	}{
+		{
+			name:           "Success: Archive is the default mode",
+			url:            "/projects/1",
+			expectedStatus: http.StatusNoContent,
+			expectedMode:   repository.ProjectDeleteArchive,
+		},
+		{
+			name:           "Success: Cascade mode",
+			url:            "/projects/1?mode=cascade",
+			expectedStatus: http.StatusNoContent,
+			expectedMode:   repository.ProjectDeleteCascade,
+		},
+		{
+			name:           "Success: Reassign mode",
+			url:            "/projects/1?mode=reassign&target_project_id=2",
+			expectedStatus: http.StatusNoContent,
+			expectedMode:   repository.ProjectDeleteReassign,
+			expectedTarget: 2,
+		},
+		{
+			name:           "Error: Reassign without target",
+			url:            "/projects/1?mode=reassign",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Error: Reassign to a missing project",
+			url:            "/projects/1?mode=reassign&target_project_id=999",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Error: Unknown mode",
+			url:            "/projects/1?mode=purge",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Error: Project not found",
+			url:            "/projects/999?mode=cascade",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockProjectRepository{
+				getByIDFunc: func(ctx context.Context, id int64) (*entity.Project, error) {
+					if id == 999 {
+						return nil, nil
+					}
+					return &entity.Project{ID: id, Name: "仕事"}, nil
+				},
+				deleteFunc: func(ctx context.Context, id int64, mode repository.ProjectDeleteMode, targetID int64) error {
+					if mode != tt.expectedMode {
+						t.Errorf("expected mode %s, got %s", tt.expectedMode, mode)
+					}
+					if targetID != tt.expectedTarget {
+						t.Errorf("expected target %d, got %d", tt.expectedTarget, targetID)
+					}
+					return nil
+				},
+			}
+
+			handler := NewProjectHandler(mockRepo)
+			req := httptest.NewRequest(http.MethodDelete, tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
@@ -1,57 +1,112 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
-	"strings"
-	"time"
 
 	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
 	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
 	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
 )
 
 type TaskHandler struct {
-	repo repository.TaskRepository
+	repo        repository.TaskRepository
+	projectRepo repository.ProjectRepository
+	mux         *router.Router
 }
 
-func NewTaskHandler(repo repository.TaskRepository) *TaskHandler {
-	return &TaskHandler{repo: repo}
+func NewTaskHandler(repo repository.TaskRepository, projectRepo repository.ProjectRepository) *TaskHandler {
+	h := &TaskHandler{repo: repo, projectRepo: projectRepo}
+	h.mux = router.New(h.Routes()...)
+	return h
 }
 
+// The limits follow the columns in _tools/*/schema.sql
 type CreateTaskRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	DueDate     string `json:"due_date"`
-	Status      string `json:"status"`
-	OwnerID     int64  `json:"owner_id"`
+	Title       string `json:"title" validate:"required,max=30"`
+	Description string `json:"description" validate:"max=255"`
+	DueDate     string `json:"due_date" validate:"required,date"`
+	Status      string `json:"status" validate:"required,oneof=ToDo Doing Done"`
+	OwnerID     int64  `json:"owner_id" validate:"required,min=1"`
+	ProjectID   *int64 `json:"project_id,omitempty" validate:"min=1"`
 }
 
 type UpdateTaskRequest struct {
-	Title       *string `json:"title,omitempty"`
-	Description *string `json:"description,omitempty"`
-	DueDate     *string `json:"due_date,omitempty"`
-	Status      *string `json:"status,omitempty"`
-	OwnerID     *int64  `json:"owner_id,omitempty"`
+	Title       *string `json:"title,omitempty" validate:"required,max=30"`
+	Description *string `json:"description,omitempty" validate:"max=255"`
+	DueDate     *string `json:"due_date,omitempty" validate:"required,date"`
+	Status      *string `json:"status,omitempty" validate:"required,oneof=ToDo Doing Done"`
+	OwnerID     *int64  `json:"owner_id,omitempty" validate:"required,min=1"`
+	// Sending null takes the task out of its project
+	ProjectID common.Nullable[int64] `json:"project_id,omitempty" validate:"min=1"`
 }
 
-func (h *TaskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	switch {
-	case r.Method == http.MethodPost && r.URL.Path == "/tasks":
-		h.Create(w, r)
-	case r.Method == http.MethodGet && r.URL.Path == "/tasks":
-		h.GetAll(w, r)
-	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/users/") && strings.HasSuffix(r.URL.Path, "/tasks"):
-		h.GetByOwnerID(w, r)
-	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/tasks/"):
-		h.GetByID(w, r)
-	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/tasks/"):
-		h.Update(w, r)
-	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/tasks/"):
-		h.Delete(w, r)
-	default:
-		common.ErrorJSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+// toTask builds a new task from a validated request
+func (req CreateTaskRequest) toTask() *entity.Task {
+	return &entity.Task{
+		Title:       req.Title,
+		Description: req.Description,
+		DueDate:     req.DueDate,
+		Status:      entity.TaskStatus(req.Status),
+		OwnerID:     req.OwnerID,
+		ProjectID:   req.ProjectID,
+	}
+}
+
+// applyTo copies the fields present in the request onto task
+func (req UpdateTaskRequest) applyTo(task *entity.Task) error {
+	if req.Title == nil && req.Description == nil && req.DueDate == nil && req.Status == nil && req.OwnerID == nil && !req.ProjectID.Set {
+		return common.ErrNoUpdateFields
+	}
+
+	if req.Title != nil {
+		task.Title = *req.Title
+	}
+	if req.Description != nil {
+		task.Description = *req.Description
+	}
+	if req.DueDate != nil {
+		task.DueDate = *req.DueDate
+	}
+	if req.Status != nil {
+		task.Status = entity.TaskStatus(*req.Status)
+	}
+	if req.OwnerID != nil {
+		task.OwnerID = *req.OwnerID
+	}
+	if req.ProjectID.Set {
+		task.ProjectID = req.ProjectID.Value
 	}
+	return nil
+}
+
+type MoveTasksRequest struct {
+	TaskIDs []int64 `json:"task_ids" validate:"required"`
+}
+
+// Routes lists the endpoints served by the handler
+func (h *TaskHandler) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodPost, Pattern: "/tasks", Handler: h.Create},
+		{Method: http.MethodGet, Pattern: "/tasks", Handler: h.GetAll},
+		{Method: http.MethodPost, Pattern: "/tasks/bulk", Handler: h.Bulk},
+		{Method: http.MethodPost, Pattern: "/tasks/import", Handler: h.Import},
+		{Method: http.MethodGet, Pattern: "/tasks/export", Handler: h.Export},
+		{Method: http.MethodGet, Pattern: "/tasks/{id}", Handler: h.GetByID},
+		{Method: http.MethodPatch, Pattern: "/tasks/{id}", Handler: h.Update},
+		{Method: http.MethodDelete, Pattern: "/tasks/{id}", Handler: h.Delete},
+		{Method: http.MethodPost, Pattern: "/tasks/{id}/archive", Handler: h.Archive},
+		{Method: http.MethodPost, Pattern: "/tasks/{id}/unarchive", Handler: h.Unarchive},
+		{Method: http.MethodPost, Pattern: "/tasks/{id}/restore", Handler: h.Restore},
+		{Method: http.MethodGet, Pattern: "/users/{id}/tasks", Handler: h.GetByOwnerID},
+		{Method: http.MethodGet, Pattern: "/projects/{id}/tasks", Handler: h.GetByProjectID},
+		{Method: http.MethodPost, Pattern: "/projects/{id}/tasks", Handler: h.MoveToProject},
+	}
+}
+
+// ServeHTTP serves the routes of the handler on their own
+func (h *TaskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
 }
 
 func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -60,30 +115,19 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req CreateTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := common.DecodeJSON(r, &req); err != nil {
 		common.HandleError(w, err)
 		return
 	}
 
-	if _, err := time.Parse("2006-01-02", req.DueDate); err != nil {
-		common.ErrorJSONResponse(w, http.StatusBadRequest, "invalid due_date format. expected format: YYYY-MM-DD")
-		return
-	}
-
-	task := &entity.Task{
-		Title:       req.Title,
-		Description: req.Description,
-		DueDate:     req.DueDate,
-		Status:      entity.TaskStatus(req.Status),
-		OwnerID:     req.OwnerID,
-	}
-
+	task := req.toTask()
 	if err := h.repo.Create(r.Context(), task); err != nil {
 		common.HandleError(w, err)
 		return
 	}
 
-	common.JSONResponse(w, http.StatusCreated, task)
+	w.Header().Set("ETag", common.VersionETag(task.Version))
+	common.JSONResponse(w, r, http.StatusCreated, task)
 }
 
 func (h *TaskHandler) GetAll(w http.ResponseWriter, r *http.Request) {
@@ -91,13 +135,19 @@ func (h *TaskHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tasks, err := h.repo.GetAll(r.Context())
+	opts, err := taskListOptions(r)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	tasks, err := h.repo.GetAll(r.Context(), opts)
 	if err != nil {
 		common.HandleError(w, err)
 		return
 	}
 
-	common.JSONResponse(w, http.StatusOK, tasks)
+	common.ConditionalJSONResponse(w, r, tasks)
 }
 
 func (h *TaskHandler) GetByID(w http.ResponseWriter, r *http.Request) {
@@ -105,7 +155,7 @@ func (h *TaskHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := common.ExtractIDFromPath(r.URL.Path, "/tasks/")
+	id, err := common.PathID(r, "id")
 	if err != nil {
 		common.HandleError(w, common.ErrInvalidID)
 		return
@@ -122,7 +172,11 @@ func (h *TaskHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	common.JSONResponse(w, http.StatusOK, task)
+	if common.CheckNotModified(w, r, common.VersionETag(task.Version), task.UpdatedAt) {
+		return
+	}
+
+	common.JSONResponse(w, r, http.StatusOK, task)
 }
 
 func (h *TaskHandler) GetByOwnerID(w http.ResponseWriter, r *http.Request) {
@@ -130,19 +184,94 @@ func (h *TaskHandler) GetByOwnerID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ownerID, err := common.ExtractOwnerIDFromPath(r.URL.Path)
+	ownerID, err := common.PathID(r, "id")
+	if err != nil {
+		common.HandleError(w, common.ErrInvalidOwnerID)
+		return
+	}
+
+	opts, err := taskListOptions(r)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	tasks, err := h.repo.GetByOwnerID(r.Context(), ownerID, opts)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	common.ConditionalJSONResponse(w, r, tasks)
+}
+
+func (h *TaskHandler) GetByProjectID(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	projectID, err := common.PathID(r, "id")
+	if err != nil {
+		common.HandleError(w, common.ErrInvalidProjectID)
+		return
+	}
+
+	opts, err := taskListOptions(r)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	// An unknown project is not found rather than a project without tasks
+	project, err := h.projectRepo.GetByID(r.Context(), projectID)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+	if project == nil {
+		common.HandleError(w, common.ErrNotFound)
+		return
+	}
+
+	tasks, err := h.repo.GetByProjectID(r.Context(), projectID, opts)
 	if err != nil {
 		common.HandleError(w, err)
 		return
 	}
 
-	tasks, err := h.repo.GetByOwnerID(r.Context(), ownerID)
+	common.JSONResponse(w, r, http.StatusOK, tasks)
+}
+
+// MoveToProject moves the tasks listed in the request body into the project from the path
+func (h *TaskHandler) MoveToProject(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	projectID, err := common.PathID(r, "id")
 	if err != nil {
+		common.HandleError(w, common.ErrInvalidProjectID)
+		return
+	}
+
+	var req MoveTasksRequest
+	if err := common.DecodeJSON(r, &req); err != nil {
 		common.HandleError(w, err)
 		return
 	}
 
-	common.JSONResponse(w, http.StatusOK, tasks)
+	if err := h.repo.MoveToProject(r.Context(), projectID, req.TaskIDs); err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	tasks, err := h.repo.GetByProjectID(r.Context(), projectID, repository.TaskListOptions{IncludeArchived: true})
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	common.JSONResponse(w, r, http.StatusOK, tasks)
 }
 
 func (h *TaskHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -150,7 +279,7 @@ func (h *TaskHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := common.ExtractIDFromPath(r.URL.Path, "/tasks/")
+	id, err := common.PathID(r, "id")
 	if err != nil {
 		common.HandleError(w, common.ErrInvalidID)
 		return
@@ -167,56 +296,137 @@ func (h *TaskHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := common.CheckIfMatch(r, existingTask.Version); err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
 	var req UpdateTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := common.DecodeJSON(r, &req); err != nil {
 		common.HandleError(w, err)
 		return
 	}
 
-	if req.Title != nil {
-		existingTask.Title = *req.Title
+	if err := req.applyTo(existingTask); err != nil {
+		common.HandleError(w, err)
+		return
 	}
-	if req.Description != nil {
-		existingTask.Description = *req.Description
+
+	if err := h.repo.Update(r.Context(), existingTask); err != nil {
+		common.HandleError(w, err)
+		return
 	}
-	if req.DueDate != nil {
-		existingTask.DueDate = *req.DueDate
+
+	w.Header().Set("ETag", common.VersionETag(existingTask.Version))
+	common.JSONResponse(w, r, http.StatusOK, existingTask)
+}
+
+func (h *TaskHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodDelete) {
+		return
 	}
-	if req.Status != nil {
-		existingTask.Status = entity.TaskStatus(*req.Status)
+
+	id, err := common.PathID(r, "id")
+	if err != nil {
+		common.HandleError(w, common.ErrInvalidID)
+		return
 	}
-	if req.OwnerID != nil {
-		existingTask.OwnerID = *req.OwnerID
+
+	// Without If-Match the task is deleted whatever its version
+	var version int64
+	if r.Header.Get("If-Match") != "" {
+		task, err := h.repo.GetByID(r.Context(), id)
+		if err != nil {
+			common.HandleError(w, err)
+			return
+		}
+
+		if task == nil {
+			common.HandleError(w, common.ErrNotFound)
+			return
+		}
+
+		if err := common.CheckIfMatch(r, task.Version); err != nil {
+			common.HandleError(w, err)
+			return
+		}
+		version = task.Version
 	}
 
-	if req.Title == nil && req.Description == nil && req.DueDate == nil && req.Status == nil && req.OwnerID == nil {
-		common.ErrorJSONResponse(w, http.StatusBadRequest, "at least one field must be provided for update")
+	if err := h.repo.Delete(r.Context(), id, version); err != nil {
+		common.HandleError(w, err)
 		return
 	}
 
-	if err := h.repo.Update(r.Context(), existingTask); err != nil {
+	common.JSONResponse(w, r, http.StatusNoContent, nil)
+}
+
+func (h *TaskHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+func (h *TaskHandler) Unarchive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+func (h *TaskHandler) setArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	if !common.ValidateRequestMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	id, err := common.PathID(r, "id")
+	if err != nil {
+		common.HandleError(w, common.ErrInvalidID)
+		return
+	}
+
+	task, err := h.repo.SetArchived(r.Context(), id, archived)
+	if err != nil {
 		common.HandleError(w, err)
 		return
 	}
 
-	common.JSONResponse(w, http.StatusOK, existingTask)
+	if task == nil {
+		common.HandleError(w, common.ErrNotFound)
+		return
+	}
+
+	w.Header().Set("ETag", common.VersionETag(task.Version))
+	common.JSONResponse(w, r, http.StatusOK, task)
 }
 
-func (h *TaskHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	if !common.ValidateRequestMethod(w, r, http.MethodDelete) {
+// Restore takes a task out of the trash
+func (h *TaskHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodPost) {
 		return
 	}
 
-	id, err := common.ExtractIDFromPath(r.URL.Path, "/tasks/")
+	id, err := common.PathID(r, "id")
 	if err != nil {
 		common.HandleError(w, common.ErrInvalidID)
 		return
 	}
 
-	if err := h.repo.Delete(r.Context(), id); err != nil {
+	task, err := h.repo.Restore(r.Context(), id)
+	if err != nil {
 		common.HandleError(w, err)
 		return
 	}
 
-	common.JSONResponse(w, http.StatusNoContent, nil)
+	if task == nil {
+		common.HandleError(w, common.ErrNotFound)
+		return
+	}
+
+	w.Header().Set("ETag", common.VersionETag(task.Version))
+	common.JSONResponse(w, r, http.StatusOK, task)
+}
+
+// taskListOptions reads the listing filters from the query string
+func taskListOptions(r *http.Request) (repository.TaskListOptions, error) {
+	includeArchived, err := common.ExtractBoolQuery(r, "include_archived")
+	if err != nil {
+		return repository.TaskListOptions{}, err
+	}
+	return repository.TaskListOptions{IncludeArchived: includeArchived}, nil
 }
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+const (
+	// BulkModeAtomic commits every operation or none of them
+	BulkModeAtomic = "atomic"
+	// BulkModePartial commits the operations that succeed and reports the ones that fail
+	BulkModePartial = "partial"
+
+	// MaxBulkOperations is also the max of BulkTaskRequest.Operations
+	MaxBulkOperations = 100
+)
+
+// errBulkAborted rolls back the transaction of an atomic bulk request after an operation fails
+var errBulkAborted = errors.New("bulk request aborted")
+
+// BulkTaskOperation is a single create, update or delete. For update and delete, a non-zero
+// Version works like If-Match and fails the operation with 412 when the task has changed.
+type BulkTaskOperation struct {
+	Op      string          `json:"op"`
+	ID      int64           `json:"id,omitempty"`
+	Version int64           `json:"version,omitempty"`
+	Task    json.RawMessage `json:"task,omitempty"`
+}
+
+type BulkTaskRequest struct {
+	Mode       string              `json:"mode" validate:"oneof=atomic partial"`
+	Operations []BulkTaskOperation `json:"operations" validate:"required,max=100"`
+}
+
+type BulkTaskResult struct {
+	Index  int          `json:"index"`
+	Op     string       `json:"op"`
+	Status int          `json:"status"`
+	ID     int64        `json:"id,omitempty"`
+	Task   *entity.Task `json:"task,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+type BulkTaskResponse struct {
+	Mode      string           `json:"mode"`
+	Committed bool             `json:"committed"`
+	Results   []BulkTaskResult `json:"results"`
+}
+
+// Bulk runs create, update and delete operations in a single transaction. In atomic mode (the default)
+// any failure rolls back every operation and the response is 422; in partial mode the successful
+// operations are committed and the response is 207 when some of them failed.
+func (h *TaskHandler) Bulk(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	var req BulkTaskRequest
+	if err := common.DecodeJSON(r, &req); err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	if req.Mode == "" {
+		req.Mode = BulkModeAtomic
+	}
+
+	results := make([]BulkTaskResult, 0, len(req.Operations))
+	err := h.repo.WithTx(r.Context(), func(repo repository.TaskRepository) error {
+		for i, op := range req.Operations {
+			result := runBulkOperation(r.Context(), repo, op)
+			result.Index = i
+			results = append(results, result)
+
+			if req.Mode == BulkModeAtomic && result.Status >= http.StatusBadRequest {
+				return errBulkAborted
+			}
+		}
+		return nil
+	})
+
+	response := BulkTaskResponse{Mode: req.Mode, Results: results}
+	switch {
+	case errors.Is(err, errBulkAborted):
+		// Everything before the failed operation was rolled back with it
+		for i := range response.Results[:len(response.Results)-1] {
+			response.Results[i].Status = http.StatusFailedDependency
+			response.Results[i].Task = nil
+			response.Results[i].Error = "rolled back because another operation failed"
+		}
+		common.JSONResponse(w, r, http.StatusUnprocessableEntity, response)
+	case err != nil:
+		common.HandleError(w, err)
+	default:
+		response.Committed = true
+		status := http.StatusOK
+		for _, result := range results {
+			if result.Status >= http.StatusBadRequest {
+				status = http.StatusMultiStatus
+				break
+			}
+		}
+		common.JSONResponse(w, r, status, response)
+	}
+}
+
+// runBulkOperation applies a single operation and reports its outcome as an HTTP status
+func runBulkOperation(ctx context.Context, repo repository.TaskRepository, op BulkTaskOperation) BulkTaskResult {
+	result := BulkTaskResult{Op: op.Op, ID: op.ID}
+
+	task, status, err := applyBulkOperation(ctx, repo, op)
+	if err != nil {
+		result.Status, result.Error = common.ErrorStatus(err)
+		return result
+	}
+
+	result.Status = status
+	result.Task = task
+	if task != nil {
+		result.ID = task.ID
+	}
+	return result
+}
+
+func applyBulkOperation(ctx context.Context, repo repository.TaskRepository, op BulkTaskOperation) (*entity.Task, int, error) {
+	switch op.Op {
+	case "create":
+		var req CreateTaskRequest
+		if err := common.DecodeJSONBytes(op.Task, &req); err != nil {
+			return nil, 0, err
+		}
+
+		task := req.toTask()
+		if err := repo.Create(ctx, task); err != nil {
+			return nil, 0, err
+		}
+		return task, http.StatusCreated, nil
+
+	case "update":
+		if op.ID <= 0 {
+			return nil, 0, common.ErrInvalidID
+		}
+
+		var req UpdateTaskRequest
+		if err := common.DecodeJSONBytes(op.Task, &req); err != nil {
+			return nil, 0, err
+		}
+
+		task, err := repo.GetByID(ctx, op.ID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if task == nil {
+			return nil, 0, common.ErrNotFound
+		}
+		if op.Version != 0 && op.Version != task.Version {
+			return nil, 0, common.ErrPreconditionFailed
+		}
+
+		if err := req.applyTo(task); err != nil {
+			return nil, 0, err
+		}
+		if err := repo.Update(ctx, task); err != nil {
+			return nil, 0, err
+		}
+		return task, http.StatusOK, nil
+
+	case "delete":
+		if op.ID <= 0 {
+			return nil, 0, common.ErrInvalidID
+		}
+
+		task, err := repo.GetByID(ctx, op.ID)
+		if err != nil {
+			return nil, 0, err
+		}
+		if task == nil {
+			return nil, 0, common.ErrNotFound
+		}
+
+		if err := repo.Delete(ctx, op.ID, op.Version); err != nil {
+			return nil, 0, err
+		}
+		return nil, http.StatusNoContent, nil
+
+	default:
+		return nil, 0, common.ErrInvalidBulkOperation
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+func TestTaskHandler_Bulk(t *testing.T) {
+	today := "2025-06-15"
+	newMockRepo := func() *MockTaskRepository {
+		return &MockTaskRepository{
+			createFunc: func(ctx context.Context, task *entity.Task) error {
+				task.ID = 10
+				return nil
+			},
+			getByIDFunc: func(ctx context.Context, id int64) (*entity.Task, error) {
+				if id == 999 {
+					return nil, nil
+				}
+				return &entity.Task{ID: id, Title: "テストタスク", DueDate: today, Status: entity.TaskStatusTodo, OwnerID: 1}, nil
+			},
+			updateFunc: func(ctx context.Context, task *entity.Task) error {
+				return nil
+			},
+			deleteFunc: func(ctx context.Context, id int64, version int64) error {
+				if id == 3 {
+					return errors.New("database error")
+				}
+				return nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		requestBody       string
+		expectedStatus    int
+		expectedCommitted bool
+		expectedResults   []int
+	}{
+		{
+			name: "Success: All operations succeed",
+			requestBody: `{"operations": [
+				{"op": "create", "task": {"title": "新しいタスク", "due_date": "2025-06-15", "status": "ToDo", "owner_id": 1}},
+				{"op": "update", "id": 1, "task": {"status": "Done"}},
+				{"op": "delete", "id": 2}
+			]}`,
+			expectedStatus:    http.StatusOK,
+			expectedCommitted: true,
+			expectedResults:   []int{http.StatusCreated, http.StatusOK, http.StatusNoContent},
+		},
+		{
+			name: "Error: Atomic mode rolls back on the first failure",
+			requestBody: `{"mode": "atomic", "operations": [
+				{"op": "update", "id": 1, "task": {"status": "Done"}},
+				{"op": "update", "id": 999, "task": {"status": "Done"}},
+				{"op": "delete", "id": 2}
+			]}`,
+			expectedStatus:    http.StatusUnprocessableEntity,
+			expectedCommitted: false,
+			expectedResults:   []int{http.StatusFailedDependency, http.StatusNotFound},
+		},
+		{
+			name: "Success: Partial mode reports each failure",
+			requestBody: `{"mode": "partial", "operations": [
+				{"op": "create", "task": {"title": "新しいタスク", "due_date": "2025/06/15"}},
+				{"op": "delete", "id": 3},
+				{"op": "archive", "id": 1},
+				{"op": "delete", "id": 2}
+			]}`,
+			expectedStatus:    http.StatusMultiStatus,
+			expectedCommitted: true,
+			expectedResults:   []int{http.StatusBadRequest, http.StatusInternalServerError, http.StatusBadRequest, http.StatusNoContent},
+		},
+		{
+			name:           "Error: Unknown mode",
+			requestBody:    `{"mode": "best-effort", "operations": [{"op": "delete", "id": 2}]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Error: No operations",
+			requestBody:    `{"operations": []}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewTaskHandler(newMockRepo(), &MockProjectRepository{})
+			req := httptest.NewRequest(http.MethodPost, "/tasks/bulk", bytes.NewBufferString(tt.requestBody))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedResults == nil {
+				return
+			}
+
+			var response BulkTaskResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Committed != tt.expectedCommitted {
+				t.Errorf("expected committed %v, got %v", tt.expectedCommitted, response.Committed)
+			}
+			if len(response.Results) != len(tt.expectedResults) {
+				t.Fatalf("expected %d results, got %d", len(tt.expectedResults), len(response.Results))
+			}
+			for i, status := range tt.expectedResults {
+				if response.Results[i].Index != i {
+					t.Errorf("expected result %d to have index %d, got %d", i, i, response.Results[i].Index)
+				}
+				if response.Results[i].Status != status {
+					t.Errorf("expected result %d to have status %d, got %d", i, status, response.Results[i].Status)
+				}
+			}
+		})
+	}
+}
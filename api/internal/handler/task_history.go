@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+type TaskHistoryHandler struct {
+	repo repository.TaskHistoryRepository
+	mux  *router.Router
+}
+
+func NewTaskHistoryHandler(repo repository.TaskHistoryRepository) *TaskHistoryHandler {
+	h := &TaskHistoryHandler{repo: repo}
+	h.mux = router.New(h.Routes()...)
+	return h
+}
+
+// Routes lists the endpoints served by the handler
+func (h *TaskHistoryHandler) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Pattern: "/tasks/{id}/history", Handler: h.GetByTaskID},
+	}
+}
+
+// ServeHTTP serves the routes of the handler on their own
+func (h *TaskHistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// GetByTaskID returns the change history of a task, newest first. History is kept while the task is in the trash.
+func (h *TaskHistoryHandler) GetByTaskID(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	taskID, err := common.PathID(r, "id")
+	if err != nil {
+		common.HandleError(w, common.ErrInvalidID)
+		return
+	}
+
+	limit, offset, err := common.ExtractPagination(r)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	history, total, err := h.repo.GetByTaskID(r.Context(), taskID, limit, offset)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	common.JSONResponse(w, r, http.StatusOK, common.PaginatedResponse{
+		Items:  history,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
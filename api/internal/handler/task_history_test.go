@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+// MockTaskHistoryRepository is a mock implementation of repository.TaskHistoryRepository
+type MockTaskHistoryRepository struct {
+	getByTaskIDFunc func(ctx context.Context, taskID int64, limit, offset int) ([]entity.TaskHistory, int64, error)
+}
+
+func (m *MockTaskHistoryRepository) GetByTaskID(ctx context.Context, taskID int64, limit, offset int) ([]entity.TaskHistory, int64, error) {
+	return m.getByTaskIDFunc(ctx, taskID, limit, offset)
+}
+
+func TestTaskHistoryHandler_GetByTaskID(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedLimit  int
+		expectedOffset int
+	}{
+		{
+			name:           "Success: Default pagination",
+			url:            "/tasks/1/history",
+			expectedStatus: http.StatusOK,
+			expectedLimit:  20,
+			expectedOffset: 0,
+		},
+		{
+			name:           "Success: Explicit pagination",
+			url:            "/tasks/1/history?limit=5&offset=10",
+			expectedStatus: http.StatusOK,
+			expectedLimit:  5,
+			expectedOffset: 10,
+		},
+		{
+			name:           "Error: Limit exceeds maximum",
+			url:            "/tasks/1/history?limit=1000",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Error: Task not found",
+			url:            "/tasks/999/history",
+			expectedStatus: http.StatusNotFound,
+			expectedLimit:  20,
+			expectedOffset: 0,
+		},
+		{
+			name:           "Error: Invalid task ID",
+			url:            "/tasks/abc/history",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldValue, newValue := "ToDo", "Done"
+			mockRepo := &MockTaskHistoryRepository{
+				getByTaskIDFunc: func(ctx context.Context, taskID int64, limit, offset int) ([]entity.TaskHistory, int64, error) {
+					if limit != tt.expectedLimit || offset != tt.expectedOffset {
+						t.Errorf("expected limit %d offset %d, got limit %d offset %d", tt.expectedLimit, tt.expectedOffset, limit, offset)
+					}
+					if taskID == 999 {
+						return nil, 0, common.ErrNotFound
+					}
+					return []entity.TaskHistory{{
+						ID:       1,
+						TaskID:   taskID,
+						Action:   entity.TaskHistoryActionUpdate,
+						Field:    "status",
+						OldValue: &oldValue,
+						NewValue: &newValue,
+						Actor:    "1",
+					}}, 1, nil
+				},
+			}
+
+			handler := NewTaskHistoryHandler(mockRepo)
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var response struct {
+					Items []entity.TaskHistory `json:"items"`
+					Total int64                `json:"total"`
+				}
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Errorf("failed to decode response: %v", err)
+				}
+				if response.Total != 1 || len(response.Items) != 1 {
+					t.Errorf("expected 1 history entry, got total %d with %d items", response.Total, len(response.Items))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/internal/taskio"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+const (
+	ImportRowCreated   = "created"
+	ImportRowValid     = "valid"
+	ImportRowDuplicate = "duplicate"
+	ImportRowInvalid   = "invalid"
+	ImportRowFailed    = "failed"
+)
+
+type ImportRowResult struct {
+	Row    int      `json:"row"`
+	Status string   `json:"status"`
+	ID     int64    `json:"id,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+type ImportTaskResponse struct {
+	Format  taskio.Format     `json:"format"`
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// Export streams every task matching the filters in the requested format. Rows are written as they
+// are read from the database, so once the first row is out an error can only cut the response short.
+func (h *TaskHandler) Export(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	format, err := taskio.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		common.ErrorJSONResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	opts, err := taskExportOptions(r)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	var enc taskio.Encoder
+	start := func() (err error) {
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, format.Extension()))
+		w.WriteHeader(http.StatusOK)
+		enc, err = taskio.NewEncoder(w, format)
+		return err
+	}
+
+	err = h.repo.Stream(r.Context(), opts, func(task *entity.Task) error {
+		if enc == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return enc.Encode(task)
+	})
+	if err != nil {
+		if enc == nil {
+			common.HandleError(w, err)
+			return
+		}
+		common.Logger(r.Context()).Error("task export aborted", slog.Any("error", err))
+		return
+	}
+
+	if enc == nil {
+		if err := start(); err != nil {
+			common.Logger(r.Context()).Error("task export aborted", slog.Any("error", err))
+			return
+		}
+	}
+	if err := enc.Close(); err != nil {
+		common.Logger(r.Context()).Error("task export aborted", slog.Any("error", err))
+	}
+}
+
+// Import creates tasks from an uploaded file. Every row is validated and checked for duplicates,
+// within the file and against existing tasks of the same owner, and the outcome of each row is
+// reported. With dry_run=true nothing is written. A row that fails to insert does not affect the others.
+func (h *TaskHandler) Import(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	format, err := taskio.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		common.ErrorJSONResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dryRun, err := common.ExtractBoolQuery(r, "dry_run")
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	dec, err := taskio.NewDecoder(r.Body, format)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		common.HandleError(w, err)
+		return
+	}
+	if err != nil {
+		common.ErrorJSONResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid %s input: %v", format, err))
+		return
+	}
+
+	response := ImportTaskResponse{Format: format, DryRun: dryRun, Rows: []ImportRowResult{}}
+	keys := newTaskKeySet(h.repo)
+
+	err = h.repo.WithTx(r.Context(), func(repo repository.TaskRepository) error {
+		for row := 1; ; row++ {
+			record, err := dec.Next()
+			if err == io.EOF {
+				return nil
+			}
+
+			result := ImportRowResult{Row: row}
+			var rowErr *taskio.RowError
+			switch {
+			case errors.As(err, &rowErr):
+				result.Status = ImportRowInvalid
+				result.Errors = []string{rowErr.Error()}
+			case errors.As(err, &tooLarge):
+				return err
+			case err != nil:
+				return fmt.Errorf("%w: row %d: %v", common.ErrInvalidRequestBody, row, err)
+			default:
+				result = importRow(r.Context(), repo, keys, record, dryRun)
+				result.Row = row
+			}
+
+			response.Rows = append(response.Rows, result)
+			switch result.Status {
+			case ImportRowCreated:
+				response.Created++
+			case ImportRowDuplicate:
+				response.Skipped++
+			case ImportRowInvalid, ImportRowFailed:
+				response.Failed++
+			}
+		}
+	})
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	response.Total = len(response.Rows)
+	status := http.StatusOK
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	common.JSONResponse(w, r, status, response)
+}
+
+// importRow validates a record and, unless this is a dry run, inserts it under its own savepoint
+func importRow(ctx context.Context, repo repository.TaskRepository, keys *taskKeySet, record *taskio.Record, dryRun bool) ImportRowResult {
+	if errs := validateImportRecord(record); len(errs) > 0 {
+		return ImportRowResult{Status: ImportRowInvalid, Errors: errs}
+	}
+
+	duplicate, err := keys.contains(ctx, record)
+	if err != nil {
+		return ImportRowResult{Status: ImportRowFailed, Errors: []string{common.ErrInternalServer.Error()}}
+	}
+	if duplicate {
+		return ImportRowResult{Status: ImportRowDuplicate, Errors: []string{"a task with the same owner_id, title and due_date already exists"}}
+	}
+	keys.add(record)
+
+	if dryRun {
+		return ImportRowResult{Status: ImportRowValid}
+	}
+
+	task := &entity.Task{
+		Title:       record.Title,
+		Description: record.Description,
+		DueDate:     record.DueDate,
+		Status:      record.Status,
+		OwnerID:     record.OwnerID,
+		ProjectID:   record.ProjectID,
+	}
+	err = repo.WithTx(ctx, func(repo repository.TaskRepository) error {
+		return repo.Create(ctx, task)
+	})
+	if err != nil {
+		_, message := common.ErrorStatus(err)
+		return ImportRowResult{Status: ImportRowFailed, Errors: []string{message}}
+	}
+	return ImportRowResult{Status: ImportRowCreated, ID: task.ID}
+}
+
+// validateImportRecord checks a record with the same rules as the body of POST /tasks
+func validateImportRecord(record *taskio.Record) []string {
+	var validationErr *common.ValidationError
+	if !errors.As(common.Validate(record), &validationErr) {
+		return nil
+	}
+
+	errs := make([]string, len(validationErr.Fields))
+	for i, field := range validationErr.Fields {
+		errs[i] = field.Message
+	}
+	return errs
+}
+
+// taskKeySet tracks the owner, title and due date of known tasks. Existing tasks are loaded one
+// owner at a time the first time that owner appears in the import.
+type taskKeySet struct {
+	repo   repository.TaskRepository
+	loaded map[int64]bool
+	keys   map[string]bool
+}
+
+func newTaskKeySet(repo repository.TaskRepository) *taskKeySet {
+	return &taskKeySet{repo: repo, loaded: map[int64]bool{}, keys: map[string]bool{}}
+}
+
+func taskKey(ownerID int64, title, dueDate string) string {
+	return strconv.FormatInt(ownerID, 10) + "\x00" + title + "\x00" + dueDate
+}
+
+func (s *taskKeySet) contains(ctx context.Context, record *taskio.Record) (bool, error) {
+	if !s.loaded[record.OwnerID] {
+		tasks, err := s.repo.GetByOwnerID(ctx, record.OwnerID, repository.TaskListOptions{IncludeArchived: true})
+		if err != nil {
+			return false, err
+		}
+		for _, task := range tasks {
+			s.keys[taskKey(task.OwnerID, task.Title, task.DueDate)] = true
+		}
+		s.loaded[record.OwnerID] = true
+	}
+	return s.keys[taskKey(record.OwnerID, record.Title, record.DueDate)], nil
+}
+
+func (s *taskKeySet) add(record *taskio.Record) {
+	s.keys[taskKey(record.OwnerID, record.Title, record.DueDate)] = true
+}
+
+// taskExportOptions extends the listing filters with status, owner_id and project_id
+func taskExportOptions(r *http.Request) (repository.TaskListOptions, error) {
+	opts, err := taskListOptions(r)
+	if err != nil {
+		return opts, err
+	}
+
+	query := r.URL.Query()
+	if status := query.Get("status"); status != "" {
+		opts.Status = entity.TaskStatus(status)
+	}
+	if value := query.Get("owner_id"); value != "" {
+		ownerID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || ownerID <= 0 {
+			return opts, common.ErrInvalidOwnerID
+		}
+		opts.OwnerID = &ownerID
+	}
+	if value := query.Get("project_id"); value != "" {
+		projectID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || projectID <= 0 {
+			return opts, common.ErrInvalidProjectID
+		}
+		opts.ProjectID = &projectID
+	}
+	return opts, nil
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+)
+
+func TestTaskHandler_Export(t *testing.T) {
+	tasks := []entity.Task{
+		{ID: 1, Title: "タスク1", DueDate: "2024-01-01", Status: entity.TaskStatusTodo, OwnerID: 1},
+		{ID: 2, Title: "タスク2", DueDate: "2024-01-02", Status: entity.TaskStatusDone, OwnerID: 1},
+	}
+
+	tests := []struct {
+		name                string
+		url                 string
+		streamErr           error
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "Success: CSV export",
+			url:                 "/tasks/export?format=csv&status=Done&owner_id=1",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,title,description,due_date,status,owner_id,project_id,archived,created_at,updated_at\n1,タスク1,",
+		},
+		{
+			name:                "Success: NDJSON export",
+			url:                 "/tasks/export?format=ndjson",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedBody:        `{"id":1,`,
+		},
+		{
+			name:                "Success: JSON export is the default",
+			url:                 "/tasks/export",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `[{"id":1,`,
+		},
+		{
+			name:                "Success: Markdown export",
+			url:                 "/tasks/export?format=markdown",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/markdown; charset=utf-8",
+			expectedBody:        "- [ ] タスク1 due:2024-01-01 owner:1\n- [x] タスク2",
+		},
+		{
+			name:           "Error: Unsupported format",
+			url:            "/tasks/export?format=xml",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Error: Invalid owner ID",
+			url:            "/tasks/export?owner_id=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Error: Repository error before the first row",
+			url:            "/tasks/export?format=csv",
+			streamErr:      errors.New("database error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockTaskRepository{
+				streamFunc: func(ctx context.Context, opts repository.TaskListOptions, fn func(task *entity.Task) error) error {
+					if tt.streamErr != nil {
+						return tt.streamErr
+					}
+					for i := range tasks {
+						if err := fn(&tasks[i]); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			}
+
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedContentType != "" && w.Header().Get("Content-Type") != tt.expectedContentType {
+				t.Errorf("expected content type %q, got %q", tt.expectedContentType, w.Header().Get("Content-Type"))
+			}
+			if !strings.HasPrefix(w.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to start with %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTaskHandler_Import(t *testing.T) {
+	existing := []entity.Task{
+		{ID: 1, Title: "既存タスク", DueDate: "2024-01-01", Status: entity.TaskStatusTodo, OwnerID: 1},
+	}
+
+	tests := []struct {
+		name             string
+		url              string
+		body             string
+		createErr        error
+		expectedStatus   int
+		expectedCreated  int
+		expectedStatuses []string
+	}{
+		{
+			name: "Success: CSV import",
+			url:  "/tasks/import?format=csv",
+			body: "title,description,due_date,status,owner_id\n" +
+				"タスク1,説明,2024-01-02,ToDo,1\n" +
+				"タスク2,,2024-01-03,Done,1\n",
+			expectedStatus:   http.StatusOK,
+			expectedCreated:  2,
+			expectedStatuses: []string{ImportRowCreated, ImportRowCreated},
+		},
+		{
+			name: "Success: Duplicates are skipped",
+			url:  "/tasks/import?format=ndjson",
+			body: `{"title":"既存タスク","due_date":"2024-01-01","status":"ToDo","owner_id":1}` + "\n" +
+				`{"title":"タスク1","due_date":"2024-01-02","status":"ToDo","owner_id":1}` + "\n" +
+				`{"title":"タスク1","due_date":"2024-01-02","status":"Doing","owner_id":1}` + "\n",
+			expectedStatus:   http.StatusOK,
+			expectedCreated:  1,
+			expectedStatuses: []string{ImportRowDuplicate, ImportRowCreated, ImportRowDuplicate},
+		},
+		{
+			name: "Success: todo.txt import",
+			url:  "/tasks/import?format=todotxt",
+			body: "(A) 2024-01-01 電話する +家族 @自宅 due:2024-01-02 owner:1\n" +
+				"x 2024-01-05 既存タスク due:2024-01-01 owner:1\n",
+			expectedStatus:   http.StatusOK,
+			expectedCreated:  1,
+			expectedStatuses: []string{ImportRowCreated, ImportRowDuplicate},
+		},
+		{
+			name:             "Success: Dry run does not create tasks",
+			url:              "/tasks/import?format=json&dry_run=true",
+			body:             `[{"title":"タスク1","due_date":"2024-01-02","status":"ToDo","owner_id":1}]`,
+			expectedStatus:   http.StatusOK,
+			expectedCreated:  0,
+			expectedStatuses: []string{ImportRowValid},
+		},
+		{
+			name: "Partial: Invalid rows are reported",
+			url:  "/tasks/import?format=csv",
+			body: "title,due_date,status,owner_id\n" +
+				",2024-01-02,ToDo,1\n" +
+				"タスク1,2024/01/02,Unknown,abc\n" +
+				"タスク2,2024-01-02,ToDo,1\n",
+			expectedStatus:   http.StatusMultiStatus,
+			expectedCreated:  1,
+			expectedStatuses: []string{ImportRowInvalid, ImportRowInvalid, ImportRowCreated},
+		},
+		{
+			name:             "Partial: Insert failure is reported",
+			url:              "/tasks/import?format=json",
+			body:             `[{"title":"タスク1","due_date":"2024-01-02","status":"ToDo","owner_id":99}]`,
+			createErr:        errors.New("foreign key violation"),
+			expectedStatus:   http.StatusMultiStatus,
+			expectedCreated:  0,
+			expectedStatuses: []string{ImportRowFailed},
+		},
+		{
+			name:           "Error: Missing required column",
+			url:            "/tasks/import?format=csv",
+			body:           "title,due_date\nタスク1,2024-01-02\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Error: Malformed JSON",
+			url:            "/tasks/import?format=json",
+			body:           `[{"title":"タスク1",`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Error: Unsupported format",
+			url:            "/tasks/import?format=xml",
+			body:           "",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := 0
+			mockRepo := &MockTaskRepository{
+				getByOwnerIDFunc: func(ctx context.Context, ownerID int64, opts repository.TaskListOptions) ([]entity.Task, error) {
+					if !opts.IncludeArchived {
+						t.Error("expected duplicate detection to include archived tasks")
+					}
+					return existing, nil
+				},
+				createFunc: func(ctx context.Context, task *entity.Task) error {
+					if tt.createErr != nil {
+						return tt.createErr
+					}
+					created++
+					task.ID = int64(100 + created)
+					return nil
+				},
+			}
+
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+			req := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if created != tt.expectedCreated {
+				t.Errorf("expected %d tasks to be created, got %d", tt.expectedCreated, created)
+			}
+
+			if tt.expectedStatuses != nil {
+				var response ImportTaskResponse
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if response.Total != len(tt.expectedStatuses) || response.Created != tt.expectedCreated {
+					t.Errorf("expected total %d created %d, got total %d created %d", len(tt.expectedStatuses), tt.expectedCreated, response.Total, response.Created)
+				}
+				for i, status := range tt.expectedStatuses {
+					if i >= len(response.Rows) || response.Rows[i].Status != status {
+						t.Errorf("expected row %d to be %q, got %+v", i+1, status, response.Rows)
+						break
+					}
+				}
+			}
+		})
+	}
+}
@@ -5,47 +5,100 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
 	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
 )
 
 // MockTaskRepository is a mock implementation of repository.TaskRepository
 type MockTaskRepository struct {
-	createFunc       func(ctx context.Context, task *entity.Task) error
-	getAllFunc       func(ctx context.Context) ([]entity.Task, error)
-	getByIDFunc      func(ctx context.Context, id int64) (*entity.Task, error)
-	getByOwnerIDFunc func(ctx context.Context, ownerID int64) ([]entity.Task, error)
-	updateFunc       func(ctx context.Context, task *entity.Task) error
-	deleteFunc       func(ctx context.Context, id int64) error
+	createFunc         func(ctx context.Context, task *entity.Task) error
+	getAllFunc         func(ctx context.Context, opts repository.TaskListOptions) ([]entity.Task, error)
+	getByIDFunc        func(ctx context.Context, id int64) (*entity.Task, error)
+	getByOwnerIDFunc   func(ctx context.Context, ownerID int64, opts repository.TaskListOptions) ([]entity.Task, error)
+	getByProjectIDFunc func(ctx context.Context, projectID int64, opts repository.TaskListOptions) ([]entity.Task, error)
+	streamFunc         func(ctx context.Context, opts repository.TaskListOptions, fn func(task *entity.Task) error) error
+	updateFunc         func(ctx context.Context, task *entity.Task) error
+	setArchivedFunc    func(ctx context.Context, id int64, archived bool) (*entity.Task, error)
+	archiveDoneFunc    func(ctx context.Context, before time.Time) (int64, error)
+	moveToProjectFunc  func(ctx context.Context, projectID int64, taskIDs []int64) error
+	deleteFunc         func(ctx context.Context, id int64, version int64) error
+	getDeletedFunc     func(ctx context.Context) ([]entity.Task, error)
+	restoreFunc        func(ctx context.Context, id int64) (*entity.Task, error)
+	purgeDeletedFunc   func(ctx context.Context, before time.Time) (int64, error)
+	countByStatusFunc  func(ctx context.Context) (map[entity.TaskStatus]int64, error)
+}
+
+// WithTx runs fn against the mock itself; tests observe rollbacks through the returned error
+func (m *MockTaskRepository) WithTx(ctx context.Context, fn func(repo repository.TaskRepository) error) error {
+	return fn(m)
 }
 
 func (m *MockTaskRepository) Create(ctx context.Context, task *entity.Task) error {
 	return m.createFunc(ctx, task)
 }
 
-func (m *MockTaskRepository) GetAll(ctx context.Context) ([]entity.Task, error) {
-	return m.getAllFunc(ctx)
+func (m *MockTaskRepository) GetAll(ctx context.Context, opts repository.TaskListOptions) ([]entity.Task, error) {
+	return m.getAllFunc(ctx, opts)
 }
 
 func (m *MockTaskRepository) GetByID(ctx context.Context, id int64) (*entity.Task, error) {
 	return m.getByIDFunc(ctx, id)
 }
 
-func (m *MockTaskRepository) GetByOwnerID(ctx context.Context, ownerID int64) ([]entity.Task, error) {
-	return m.getByOwnerIDFunc(ctx, ownerID)
+func (m *MockTaskRepository) GetByOwnerID(ctx context.Context, ownerID int64, opts repository.TaskListOptions) ([]entity.Task, error) {
+	return m.getByOwnerIDFunc(ctx, ownerID, opts)
+}
+
+func (m *MockTaskRepository) GetByProjectID(ctx context.Context, projectID int64, opts repository.TaskListOptions) ([]entity.Task, error) {
+	return m.getByProjectIDFunc(ctx, projectID, opts)
+}
+
+func (m *MockTaskRepository) Stream(ctx context.Context, opts repository.TaskListOptions, fn func(task *entity.Task) error) error {
+	return m.streamFunc(ctx, opts, fn)
 }
 
 func (m *MockTaskRepository) Update(ctx context.Context, task *entity.Task) error {
 	return m.updateFunc(ctx, task)
 }
 
-func (m *MockTaskRepository) Delete(ctx context.Context, id int64) error {
-	return m.deleteFunc(ctx, id)
+func (m *MockTaskRepository) SetArchived(ctx context.Context, id int64, archived bool) (*entity.Task, error) {
+	return m.setArchivedFunc(ctx, id, archived)
+}
+
+func (m *MockTaskRepository) ArchiveDoneBefore(ctx context.Context, before time.Time) (int64, error) {
+	return m.archiveDoneFunc(ctx, before)
+}
+
+func (m *MockTaskRepository) MoveToProject(ctx context.Context, projectID int64, taskIDs []int64) error {
+	return m.moveToProjectFunc(ctx, projectID, taskIDs)
+}
+
+func (m *MockTaskRepository) Delete(ctx context.Context, id int64, version int64) error {
+	return m.deleteFunc(ctx, id, version)
+}
+
+func (m *MockTaskRepository) GetDeleted(ctx context.Context) ([]entity.Task, error) {
+	return m.getDeletedFunc(ctx)
+}
+
+func (m *MockTaskRepository) Restore(ctx context.Context, id int64) (*entity.Task, error) {
+	return m.restoreFunc(ctx, id)
+}
+
+func (m *MockTaskRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	return m.purgeDeletedFunc(ctx, before)
+}
+
+func (m *MockTaskRepository) CountByStatus(ctx context.Context) (map[entity.TaskStatus]int64, error) {
+	return m.countByStatusFunc(ctx)
 }
 
 func TestTaskHandler_Create(t *testing.T) {
@@ -63,7 +116,7 @@ func TestTaskHandler_Create(t *testing.T) {
 				Title:       "テストタスク",
 				Description: "テストの説明",
 				DueDate:     now,
-				Status:      "Todo",
+				Status:      string(entity.TaskStatusTodo),
 				OwnerID:     1,
 			},
 			mockSetup: func(m *MockTaskRepository) {
@@ -80,7 +133,7 @@ func TestTaskHandler_Create(t *testing.T) {
 				Title:       "テストタスク",
 				Description: "テストの説明",
 				DueDate:     now,
-				Status:      "Todo",
+				Status:      string(entity.TaskStatusTodo),
 				OwnerID:     1,
 			},
 			mockSetup: func(m *MockTaskRepository) {
@@ -97,7 +150,7 @@ func TestTaskHandler_Create(t *testing.T) {
 				Title:       "テストタスク",
 				Description: "テストの説明",
 				DueDate:     "2025/06/15", // Invalid format
-				Status:      "Todo",
+				Status:      string(entity.TaskStatusTodo),
 				OwnerID:     1,
 			},
 			mockSetup: func(m *MockTaskRepository) {
@@ -115,7 +168,7 @@ func TestTaskHandler_Create(t *testing.T) {
 			mockRepo := &MockTaskRepository{}
 			tt.mockSetup(mockRepo)
 
-			handler := NewTaskHandler(mockRepo)
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
 			body, _ := json.Marshal(tt.requestBody)
 			req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBuffer(body))
 			w := httptest.NewRecorder()
@@ -148,6 +201,107 @@ func TestTaskHandler_Create(t *testing.T) {
 	}
 }
 
+func TestTaskHandler_Create_Problem(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		createErr      error
+		expectedStatus int
+		expectedCode   string
+		expectedField  string
+	}{
+		{
+			name:           "Error: Malformed JSON",
+			body:           `{"title":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request_body",
+		},
+		{
+			name:           "Error: Empty body",
+			body:           ``,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request_body",
+		},
+		{
+			name:           "Error: Wrong field type",
+			body:           `{"title":"テストタスク","owner_id":"1"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "validation_failed",
+			expectedField:  "owner_id",
+		},
+		{
+			name:           "Error: Invalid due date",
+			body:           `{"title":"テストタスク","due_date":"2025/06/15","status":"ToDo","owner_id":1}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "validation_failed",
+			expectedField:  "due_date",
+		},
+		{
+			name:           "Error: Description longer than the column",
+			body:           `{"title":"テストタスク","description":"` + strings.Repeat("説", 256) + `","due_date":"2025-06-15","status":"ToDo","owner_id":1}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "validation_failed",
+			expectedField:  "description",
+		},
+		{
+			name:           "Error: Nonexistent owner",
+			body:           `{"title":"テストタスク","due_date":"2025-06-15","status":"ToDo","owner_id":99}`,
+			createErr:      &repository.ConstraintError{Kind: repository.ConstraintForeignKey, Field: "owner_id", Err: errors.New("fk_tasks_owner_id")},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "invalid_reference",
+			expectedField:  "owner_id",
+		},
+		{
+			name:           "Error: Repository error hides its message",
+			body:           `{"title":"テストタスク","due_date":"2025-06-15","status":"ToDo","owner_id":1}`,
+			createErr:      errors.New("database error"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "internal_server_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockTaskRepository{
+				createFunc: func(ctx context.Context, task *entity.Task) error {
+					return tt.createErr
+				},
+			}
+
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+			req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			w.Header().Set(common.RequestIDHeader, "req-1")
+
+			handler.Create(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Header().Get("Content-Type") != common.ProblemContentType {
+				t.Errorf("expected content type %q, got %q", common.ProblemContentType, w.Header().Get("Content-Type"))
+			}
+
+			var problem common.Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if problem.Code != tt.expectedCode || problem.Type != common.ProblemTypeBase+tt.expectedCode {
+				t.Errorf("expected code %q, got %q (%q)", tt.expectedCode, problem.Code, problem.Type)
+			}
+			if problem.Status != tt.expectedStatus || problem.RequestID != "req-1" {
+				t.Errorf("expected status %d and request ID, got %+v", tt.expectedStatus, problem)
+			}
+			if tt.expectedField != "" && (len(problem.Errors) != 1 || problem.Errors[0].Field != tt.expectedField) {
+				t.Errorf("expected an error for field %q, got %+v", tt.expectedField, problem.Errors)
+			}
+			if tt.expectedStatus == http.StatusInternalServerError && problem.Detail == tt.createErr.Error() {
+				t.Error("expected the internal error message to be hidden")
+			}
+		})
+	}
+}
+
 func TestTaskHandler_GetByID(t *testing.T) {
 	now := time.Now()
 	tests := []struct {
@@ -193,11 +347,11 @@ func TestTaskHandler_GetByID(t *testing.T) {
 			mockRepo := &MockTaskRepository{}
 			tt.mockSetup(mockRepo)
 
-			handler := NewTaskHandler(mockRepo)
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
 			req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
 			w := httptest.NewRecorder()
 
-			handler.GetByID(w, req)
+			handler.ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -216,12 +370,80 @@ func TestTaskHandler_GetByID(t *testing.T) {
 	}
 }
 
+func TestTaskHandler_GetByProjectID(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:           "Success: Tasks of the project are listed",
+			url:            "/projects/1/tasks",
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+		},
+		{
+			name:           "Success: A project without tasks lists nothing",
+			url:            "/projects/2/tasks",
+			expectedStatus: http.StatusOK,
+			expectedCount:  0,
+		},
+		{
+			name:           "Error: Project not found",
+			url:            "/projects/999/tasks",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockTaskRepository{
+				getByProjectIDFunc: func(ctx context.Context, projectID int64, opts repository.TaskListOptions) ([]entity.Task, error) {
+					if projectID != 1 {
+						return []entity.Task{}, nil
+					}
+					return []entity.Task{{ID: 1, Title: "タスク1", ProjectID: &projectID}}, nil
+				},
+			}
+			projectRepo := &MockProjectRepository{
+				getByIDFunc: func(ctx context.Context, id int64) (*entity.Project, error) {
+					if id == 999 {
+						return nil, nil
+					}
+					return &entity.Project{ID: id, Name: "仕事"}, nil
+				},
+			}
+
+			handler := NewTaskHandler(mockRepo, projectRepo)
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var tasks []entity.Task
+			if err := json.NewDecoder(w.Body).Decode(&tasks); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(tasks) != tt.expectedCount {
+				t.Errorf("expected %d tasks, got %d", tt.expectedCount, len(tasks))
+			}
+		})
+	}
+}
+
 func TestTaskHandler_Update(t *testing.T) {
 	now := time.Now()
 	tests := []struct {
 		name           string
 		taskID         int64
-		requestBody    UpdateTaskRequest
+		body           string
 		mockSetup      func(*MockTaskRepository)
 		expectedStatus int
 		expectedError  bool
@@ -229,11 +451,7 @@ func TestTaskHandler_Update(t *testing.T) {
 		{
 			name:   "Success: Task update succeeds",
 			taskID: 1,
-			requestBody: UpdateTaskRequest{
-				Title:       taskStringPtr("更新されたタスク"),
-				Description: taskStringPtr("更新された説明"),
-				Status:      taskStringPtr("Done"),
-			},
+			body:   `{"title": "更新されたタスク", "description": "更新された説明", "status": "Done"}`,
 			mockSetup: func(m *MockTaskRepository) {
 				m.getByIDFunc = func(ctx context.Context, id int64) (*entity.Task, error) {
 					return &entity.Task{
@@ -255,11 +473,7 @@ func TestTaskHandler_Update(t *testing.T) {
 		{
 			name:   "Error: Update fields are empty",
 			taskID: 1,
-			requestBody: UpdateTaskRequest{
-				Title:       nil,
-				Description: nil,
-				Status:      nil,
-			},
+			body:   `{}`,
 			mockSetup: func(m *MockTaskRepository) {
 				m.getByIDFunc = func(ctx context.Context, id int64) (*entity.Task, error) {
 					return &entity.Task{
@@ -282,30 +496,749 @@ func TestTaskHandler_Update(t *testing.T) {
 			mockRepo := &MockTaskRepository{}
 			tt.mockSetup(mockRepo)
 
-			handler := NewTaskHandler(mockRepo)
-			body, _ := json.Marshal(tt.requestBody)
-			req := httptest.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBuffer(body))
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+			req := httptest.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBufferString(tt.body))
 			w := httptest.NewRecorder()
 
-			handler.Update(w, req)
+			handler.ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
 			}
 
 			if !tt.expectedError {
+				var requestBody UpdateTaskRequest
+				if err := json.Unmarshal([]byte(tt.body), &requestBody); err != nil {
+					t.Fatalf("failed to decode request: %v", err)
+				}
 				var response entity.Task
 				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
 					t.Errorf("failed to decode response: %v", err)
 				}
-				if tt.requestBody.Title != nil && response.Title != *tt.requestBody.Title {
-					t.Errorf("expected title %s, got %s", *tt.requestBody.Title, response.Title)
+				if requestBody.Title != nil && response.Title != *requestBody.Title {
+					t.Errorf("expected title %s, got %s", *requestBody.Title, response.Title)
+				}
+				if requestBody.Description != nil && response.Description != *requestBody.Description {
+					t.Errorf("expected description %s, got %s", *requestBody.Description, response.Description)
 				}
-				if tt.requestBody.Description != nil && response.Description != *tt.requestBody.Description {
-					t.Errorf("expected description %s, got %s", *tt.requestBody.Description, response.Description)
+				if requestBody.Status != nil && response.Status != entity.TaskStatus(*requestBody.Status) {
+					t.Errorf("expected status %s, got %s", *requestBody.Status, response.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestTaskHandler_Update_ProjectID(t *testing.T) {
+	tests := []struct {
+		name              string
+		body              string
+		expectedStatus    int
+		expectedProjectID *int64
+	}{
+		{
+			name:              "Success: Omitted project_id keeps the project",
+			body:              `{"title": "更新されたタスク"}`,
+			expectedStatus:    http.StatusOK,
+			expectedProjectID: taskInt64Ptr(3),
+		},
+		{
+			name:              "Success: project_id moves the task to another project",
+			body:              `{"project_id": 5}`,
+			expectedStatus:    http.StatusOK,
+			expectedProjectID: taskInt64Ptr(5),
+		},
+		{
+			name:           "Success: Null project_id takes the task out of its project",
+			body:           `{"project_id": null}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Error: project_id is not positive",
+			body:           `{"project_id": 0}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *entity.Task
+			mockRepo := &MockTaskRepository{
+				getByIDFunc: func(ctx context.Context, id int64) (*entity.Task, error) {
+					return &entity.Task{ID: id, Title: "テストタスク", OwnerID: 1, ProjectID: taskInt64Ptr(3), Version: 1}, nil
+				},
+				updateFunc: func(ctx context.Context, task *entity.Task) error {
+					updated = task
+					return nil
+				},
+			}
+
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+			req := httptest.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if tt.expectedProjectID == nil {
+				if updated.ProjectID != nil {
+					t.Errorf("expected no project, got %d", *updated.ProjectID)
+				}
+			} else if updated.ProjectID == nil || *updated.ProjectID != *tt.expectedProjectID {
+				t.Errorf("expected project %d, got %v", *tt.expectedProjectID, updated.ProjectID)
+			}
+		})
+	}
+}
+
+func TestTaskHandler_Update_IfMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		ifMatch        string
+		updateErr      error
+		expectedStatus int
+		expectedETag   string
+	}{
+		{
+			name:           "Success: If-Match matches the current version",
+			ifMatch:        `"3"`,
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4"`,
+		},
+		{
+			name:           "Success: If-Match names the compressed ETag",
+			ifMatch:        `W/"3"`,
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4"`,
+		},
+		{
+			name:           "Success: If-Match wildcard",
+			ifMatch:        "*",
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4"`,
+		},
+		{
+			name:           "Success: Without If-Match",
+			expectedStatus: http.StatusOK,
+			expectedETag:   `"4"`,
+		},
+		{
+			name:           "Error: If-Match names an older version",
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "Error: Concurrent update between read and write",
+			ifMatch:        `"3"`,
+			updateErr:      common.ErrPreconditionFailed,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := false
+			mockRepo := &MockTaskRepository{
+				getByIDFunc: func(ctx context.Context, id int64) (*entity.Task, error) {
+					return &entity.Task{ID: id, Title: "テストタスク", DueDate: "2024-01-01", Status: entity.TaskStatusTodo, OwnerID: 1, Version: 3}, nil
+				},
+				updateFunc: func(ctx context.Context, task *entity.Task) error {
+					updated = true
+					if task.Version != 3 {
+						t.Errorf("expected the update to be conditional on version 3, got %d", task.Version)
+					}
+					if tt.updateErr != nil {
+						return tt.updateErr
+					}
+					task.Version++
+					return nil
+				},
+			}
+
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+			req := httptest.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBufferString(`{"status":"Done"}`))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Header().Get("ETag") != tt.expectedETag {
+				t.Errorf("expected ETag %q, got %q", tt.expectedETag, w.Header().Get("ETag"))
+			}
+			if tt.ifMatch == `"2"` && updated {
+				t.Error("expected a stale If-Match to be rejected before updating")
+			}
+		})
+	}
+}
+
+func TestTaskHandler_Delete_IfMatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		ifMatch         string
+		expectedStatus  int
+		expectedVersion int64
+	}{
+		{
+			name:            "Success: Unconditional delete",
+			expectedStatus:  http.StatusNoContent,
+			expectedVersion: 0,
+		},
+		{
+			name:            "Success: Conditional delete",
+			ifMatch:         `"3"`,
+			expectedStatus:  http.StatusNoContent,
+			expectedVersion: 3,
+		},
+		{
+			name:           "Error: Stale If-Match",
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockTaskRepository{
+				getByIDFunc: func(ctx context.Context, id int64) (*entity.Task, error) {
+					return &entity.Task{ID: id, Version: 3}, nil
+				},
+				deleteFunc: func(ctx context.Context, id int64, version int64) error {
+					if version != tt.expectedVersion {
+						t.Errorf("expected delete at version %d, got %d", tt.expectedVersion, version)
+					}
+					return nil
+				},
+			}
+
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+			req := httptest.NewRequest(http.MethodDelete, "/tasks/1", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestTaskHandler_MissingTask(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		ifMatch        string
+		body           string
+		found          bool
+		repoErr        error
+		expectedStatus int
+	}{
+		{
+			name:           "Error: Delete of a nonexistent task",
+			method:         http.MethodDelete,
+			repoErr:        common.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Error: Conditional delete of a nonexistent task",
+			method:         http.MethodDelete,
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Error: Update of a nonexistent task",
+			method:         http.MethodPatch,
+			body:           `{"title":"更新後のタスク"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Error: Task deleted while it was being updated",
+			method:         http.MethodPatch,
+			body:           `{"title":"更新後のタスク"}`,
+			found:          true,
+			repoErr:        common.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Error: Task changed while it was being updated",
+			method:         http.MethodPatch,
+			body:           `{"title":"更新後のタスク"}`,
+			found:          true,
+			repoErr:        common.ErrPreconditionFailed,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockTaskRepository{
+				getByIDFunc: func(ctx context.Context, id int64) (*entity.Task, error) {
+					if !tt.found {
+						return nil, nil
+					}
+					return &entity.Task{ID: id, Title: "テストタスク", DueDate: "2025-06-15", Status: entity.TaskStatusTodo, OwnerID: 1, Version: 1}, nil
+				},
+				updateFunc: func(ctx context.Context, task *entity.Task) error {
+					return tt.repoErr
+				},
+				deleteFunc: func(ctx context.Context, id int64, version int64) error {
+					return tt.repoErr
+				},
+			}
+
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+			req := httptest.NewRequest(tt.method, "/tasks/1", bytes.NewBufferString(tt.body))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTaskHandler_InvalidPathID(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		expectedCode string
+	}{
+		{
+			name:         "Error: Task id is not a number",
+			method:       http.MethodGet,
+			path:         "/tasks/abc",
+			expectedCode: "invalid_id",
+		},
+		{
+			name:         "Error: Owner id is not a number",
+			method:       http.MethodGet,
+			path:         "/users/abc/tasks",
+			expectedCode: "invalid_owner_id",
+		},
+		{
+			name:         "Error: Project id is not a number",
+			method:       http.MethodGet,
+			path:         "/projects/abc/tasks",
+			expectedCode: "invalid_project_id",
+		},
+		{
+			name:         "Error: Project id to move tasks to is not a number",
+			method:       http.MethodPost,
+			path:         "/projects/abc/tasks",
+			expectedCode: "invalid_project_id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewTaskHandler(&MockTaskRepository{}, &MockProjectRepository{})
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"task_ids":[1]}`))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+			var problem common.Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if problem.Code != tt.expectedCode {
+				t.Errorf("expected code %q, got %q", tt.expectedCode, problem.Code)
+			}
+		})
+	}
+}
+
+func TestTaskHandler_MoveToProject(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestBody    MoveTasksRequest
+		mockSetup      func(*MockTaskRepository)
+		expectedStatus int
+	}{
+		{
+			name:        "Success: Tasks are moved to the project",
+			requestBody: MoveTasksRequest{TaskIDs: []int64{1, 2}},
+			mockSetup: func(m *MockTaskRepository) {
+				m.moveToProjectFunc = func(ctx context.Context, projectID int64, taskIDs []int64) error {
+					if projectID != 3 || len(taskIDs) != 2 {
+						t.Errorf("unexpected move of %v to project %d", taskIDs, projectID)
+					}
+					return nil
+				}
+				m.getByProjectIDFunc = func(ctx context.Context, projectID int64, opts repository.TaskListOptions) ([]entity.Task, error) {
+					return []entity.Task{{ID: 1, ProjectID: &projectID}, {ID: 2, ProjectID: &projectID}}, nil
+				}
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "Error: A task does not exist",
+			requestBody: MoveTasksRequest{TaskIDs: []int64{1, 99}},
+			mockSetup: func(m *MockTaskRepository) {
+				m.moveToProjectFunc = func(ctx context.Context, projectID int64, taskIDs []int64) error {
+					return fmt.Errorf("%w: task %d", common.ErrNotFound, 99)
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Error: No task ids",
+			requestBody:    MoveTasksRequest{},
+			mockSetup:      func(m *MockTaskRepository) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockTaskRepository{}
+			tt.mockSetup(mockRepo)
+
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+			body, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/projects/3/tasks", bytes.NewBuffer(body))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestTaskHandler_Restore(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockSetup      func(*MockTaskRepository)
+		expectedStatus int
+	}{
+		{
+			name: "Success: Task is restored from trash",
+			mockSetup: func(m *MockTaskRepository) {
+				m.restoreFunc = func(ctx context.Context, id int64) (*entity.Task, error) {
+					return &entity.Task{ID: id, Title: "テストタスク"}, nil
+				}
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Error: Task is not in trash",
+			mockSetup: func(m *MockTaskRepository) {
+				m.restoreFunc = func(ctx context.Context, id int64) (*entity.Task, error) {
+					return nil, nil
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Error: Owner is still in trash",
+			mockSetup: func(m *MockTaskRepository) {
+				m.restoreFunc = func(ctx context.Context, id int64) (*entity.Task, error) {
+					return nil, common.ErrOwnerInTrash
+				}
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockTaskRepository{}
+			tt.mockSetup(mockRepo)
+
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+			req := httptest.NewRequest(http.MethodPost, "/tasks/1/restore", nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestTaskHandler_GetAll(t *testing.T) {
+	tests := []struct {
+		name                    string
+		url                     string
+		expectedStatus          int
+		expectedIncludeArchived bool
+	}{
+		{
+			name:                    "Success: Archived tasks are excluded by default",
+			url:                     "/tasks",
+			expectedStatus:          http.StatusOK,
+			expectedIncludeArchived: false,
+		},
+		{
+			name:                    "Success: Archived tasks are included on request",
+			url:                     "/tasks?include_archived=true",
+			expectedStatus:          http.StatusOK,
+			expectedIncludeArchived: true,
+		},
+		{
+			name:           "Error: Invalid include_archived value",
+			url:            "/tasks?include_archived=maybe",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockTaskRepository{
+				getAllFunc: func(ctx context.Context, opts repository.TaskListOptions) ([]entity.Task, error) {
+					if opts.IncludeArchived != tt.expectedIncludeArchived {
+						t.Errorf("expected include archived %v, got %v", tt.expectedIncludeArchived, opts.IncludeArchived)
+					}
+					return []entity.Task{}, nil
+				},
+			}
+
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.GetAll(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestTaskHandler_ConditionalGet(t *testing.T) {
+	updatedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tasks := []entity.Task{
+		{ID: 1, Title: "タスク1", DueDate: "2024-01-10", Status: entity.TaskStatusTodo, OwnerID: 1, UpdatedAt: updatedAt, Version: 2},
+	}
+	mockRepo := &MockTaskRepository{
+		getAllFunc: func(ctx context.Context, opts repository.TaskListOptions) ([]entity.Task, error) {
+			return tasks, nil
+		},
+		getByIDFunc: func(ctx context.Context, id int64) (*entity.Task, error) {
+			return &tasks[0], nil
+		},
+	}
+	handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+
+	// Removing a task from a listing leaves the update times of the rest alone, so listings are only
+	// validated by their ETag
+	for _, target := range []struct {
+		path                 string
+		expectedLastModified string
+		expectedIMSStatus    int
+	}{
+		{path: "/tasks", expectedIMSStatus: http.StatusOK},
+		{path: "/tasks/1", expectedLastModified: "Tue, 02 Jan 2024 03:04:05 GMT", expectedIMSStatus: http.StatusNotModified},
+	} {
+		t.Run(target.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target.path, nil))
+			etag := w.Header().Get("ETag")
+			if w.Code != http.StatusOK || etag == "" {
+				t.Fatalf("expected 200 with an ETag, got %d with %q", w.Code, etag)
+			}
+			if w.Header().Get("Last-Modified") != target.expectedLastModified {
+				t.Errorf("expected Last-Modified %q, got %q", target.expectedLastModified, w.Header().Get("Last-Modified"))
+			}
+
+			tests := []struct {
+				name           string
+				header         string
+				value          string
+				expectedStatus int
+			}{
+				{name: "Matching If-None-Match", header: "If-None-Match", value: etag, expectedStatus: http.StatusNotModified},
+				{name: "Stale If-None-Match", header: "If-None-Match", value: `"stale"`, expectedStatus: http.StatusOK},
+				{name: "If-Modified-Since at the last update", header: "If-Modified-Since", value: "Tue, 02 Jan 2024 03:04:05 GMT", expectedStatus: target.expectedIMSStatus},
+				{name: "If-Modified-Since before the last update", header: "If-Modified-Since", value: "Mon, 01 Jan 2024 00:00:00 GMT", expectedStatus: http.StatusOK},
+			}
+			for _, tt := range tests {
+				req := httptest.NewRequest(http.MethodGet, target.path, nil)
+				req.Header.Set(tt.header, tt.value)
+				w := httptest.NewRecorder()
+
+				handler.ServeHTTP(w, req)
+
+				if w.Code != tt.expectedStatus {
+					t.Errorf("%s: expected status %d, got %d", tt.name, tt.expectedStatus, w.Code)
+				}
+				if w.Code == http.StatusNotModified && w.Body.Len() != 0 {
+					t.Errorf("%s: expected an empty body, got %q", tt.name, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestTaskHandler_Negotiation(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	projectID := int64(3)
+	tasks := []entity.Task{
+		{ID: 1, Title: "買い物", Description: "牛乳, 卵", DueDate: "2024-01-10", Status: entity.TaskStatusTodo, OwnerID: 1, CreatedAt: createdAt, UpdatedAt: createdAt, Version: 1},
+		{ID: 2, Title: "掃除", DueDate: "2024-01-11", Status: entity.TaskStatusDone, OwnerID: 1, ProjectID: &projectID, Archived: true, CreatedAt: createdAt, UpdatedAt: createdAt, Version: 2},
+	}
+	mockRepo := &MockTaskRepository{
+		getAllFunc: func(ctx context.Context, opts repository.TaskListOptions) ([]entity.Task, error) {
+			return tasks, nil
+		},
+		getByIDFunc: func(ctx context.Context, id int64) (*entity.Task, error) {
+			return &tasks[0], nil
+		},
+	}
+	handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+
+	csvBody := "id,title,description,due_date,status,owner_id,project_id,archived,created_at,updated_at,version,deleted_at\n" +
+		"1,買い物,\"牛乳, 卵\",2024-01-10,ToDo,1,,false,2024-01-02T03:04:05Z,2024-01-02T03:04:05Z,1,\n" +
+		"2,掃除,,2024-01-11,Done,1,3,true,2024-01-02T03:04:05Z,2024-01-02T03:04:05Z,2,\n"
+
+	tests := []struct {
+		name                string
+		path                string
+		accept              string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{name: "Success: No Accept header", path: "/tasks", expectedContentType: "application/json"},
+		{name: "Success: CSV", path: "/tasks", accept: "text/csv", expectedContentType: "text/csv; charset=utf-8", expectedBody: csvBody},
+		{name: "Success: NDJSON", path: "/tasks", accept: "application/x-ndjson", expectedContentType: "application/x-ndjson"},
+		{name: "Success: Highest quality wins", path: "/tasks", accept: "text/csv;q=0.5, application/x-ndjson", expectedContentType: "application/x-ndjson"},
+		{name: "Success: Media range", path: "/tasks", accept: "text/*", expectedContentType: "text/csv; charset=utf-8", expectedBody: csvBody},
+		{name: "Success: Browser defaults", path: "/tasks", accept: "text/html,application/xhtml+xml,*/*;q=0.8", expectedContentType: "application/json"},
+		{name: "Success: Unsupported type falls back to JSON", path: "/tasks", accept: "application/xml", expectedContentType: "application/json"},
+		{name: "Success: Single task is always JSON", path: "/tasks/1", accept: "text/csv", expectedContentType: "application/json"},
+	}
+
+	etags := make(map[string]string)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.expectedContentType {
+				t.Errorf("expected Content-Type %q, got %q", tt.expectedContentType, got)
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("expected body:\n%s\ngot:\n%s", tt.expectedBody, w.Body.String())
+			}
+			if tt.expectedContentType == "application/x-ndjson" {
+				lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+				if len(lines) != len(tasks) {
+					t.Fatalf("expected %d lines, got %d", len(tasks), len(lines))
+				}
+				var task entity.Task
+				if err := json.Unmarshal([]byte(lines[1]), &task); err != nil || task.ID != 2 {
+					t.Errorf("expected the second task on the second line, got %s", lines[1])
+				}
+			}
+			if tt.path == "/tasks" {
+				if w.Header().Get("Vary") != "Accept" {
+					t.Errorf("expected Vary: Accept on a list, got %q", w.Header().Get("Vary"))
+				}
+				etags[tt.expectedContentType] = w.Header().Get("ETag")
+			}
+		})
+	}
+
+	if etags["application/json"] == etags["text/csv; charset=utf-8"] {
+		t.Error("expected each representation to have its own ETag")
+	}
+}
+
+func TestTaskHandler_Archive(t *testing.T) {
+	tests := []struct {
+		name             string
+		url              string
+		found            bool
+		expectedStatus   int
+		expectedArchived bool
+	}{
+		{
+			name:             "Success: Task is archived",
+			url:              "/tasks/1/archive",
+			found:            true,
+			expectedStatus:   http.StatusOK,
+			expectedArchived: true,
+		},
+		{
+			name:             "Success: Task is unarchived",
+			url:              "/tasks/1/unarchive",
+			found:            true,
+			expectedStatus:   http.StatusOK,
+			expectedArchived: false,
+		},
+		{
+			name:           "Error: Task not found",
+			url:            "/tasks/999/archive",
+			found:          false,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockTaskRepository{
+				setArchivedFunc: func(ctx context.Context, id int64, archived bool) (*entity.Task, error) {
+					if !tt.found {
+						return nil, nil
+					}
+					return &entity.Task{ID: id, Status: entity.TaskStatusDone, Archived: archived}, nil
+				},
+			}
+
+			handler := NewTaskHandler(mockRepo, &MockProjectRepository{})
+			req := httptest.NewRequest(http.MethodPost, tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			if tt.expectedStatus == http.StatusOK {
+				var response entity.Task
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Errorf("failed to decode response: %v", err)
 				}
-				if tt.requestBody.Status != nil && response.Status != entity.TaskStatus(*tt.requestBody.Status) {
-					t.Errorf("expected status %s, got %s", *tt.requestBody.Status, response.Status)
+				if response.Archived != tt.expectedArchived {
+					t.Errorf("expected archived %v, got %v", tt.expectedArchived, response.Archived)
 				}
 			}
 		})
@@ -313,6 +1246,6 @@ func TestTaskHandler_Update(t *testing.T) {
 }
 
 // Helper function
-func taskStringPtr(s string) *string {
-	return &s
+func taskInt64Ptr(n int64) *int64 {
+	return &n
 }
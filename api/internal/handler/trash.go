@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+type TrashHandler struct {
+	taskRepo repository.TaskRepository
+	userRepo repository.UserRepository
+	mux      *router.Router
+}
+
+func NewTrashHandler(taskRepo repository.TaskRepository, userRepo repository.UserRepository) *TrashHandler {
+	h := &TrashHandler{taskRepo: taskRepo, userRepo: userRepo}
+	h.mux = router.New(h.Routes()...)
+	return h
+}
+
+// Routes lists the endpoints served by the handler
+func (h *TrashHandler) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Pattern: "/trash/tasks", Handler: h.GetTasks},
+		{Method: http.MethodGet, Pattern: "/trash/users", Handler: h.GetUsers},
+	}
+}
+
+// ServeHTTP serves the routes of the handler on their own
+func (h *TrashHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *TrashHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	tasks, err := h.taskRepo.GetDeleted(r.Context())
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	common.JSONResponse(w, r, http.StatusOK, tasks)
+}
+
+func (h *TrashHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	users, err := h.userRepo.GetDeleted(r.Context())
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	common.JSONResponse(w, r, http.StatusOK, users)
+}
@@ -1,63 +1,65 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
-	"strings"
 
 	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
 	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
 	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
 )
 
 type UserHandler struct {
 	repo repository.UserRepository
+	mux  *router.Router
 }
 
 func NewUserHandler(repo repository.UserRepository) *UserHandler {
-	return &UserHandler{repo: repo}
+	h := &UserHandler{repo: repo}
+	h.mux = router.New(h.Routes()...)
+	return h
 }
 
+// The limits follow the columns in _tools/*/schema.sql
 type CreateUserRequest struct {
-	Username  string `json:"username"`
-	Email     string `json:"email"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
+	Username  string `json:"username" validate:"required,max=30"`
+	Email     string `json:"email" validate:"required,max=80,email"`
+	FirstName string `json:"first_name" validate:"max=40"`
+	LastName  string `json:"last_name" validate:"max=40"`
 }
 
 type UpdateUserRequest struct {
-	Username  *string `json:"username,omitempty"`
-	Email     *string `json:"email,omitempty"`
-	FirstName *string `json:"first_name,omitempty"`
-	LastName  *string `json:"last_name,omitempty"`
+	Username  *string `json:"username,omitempty" validate:"required,max=30"`
+	Email     *string `json:"email,omitempty" validate:"required,max=80,email"`
+	FirstName *string `json:"first_name,omitempty" validate:"max=40"`
+	LastName  *string `json:"last_name,omitempty" validate:"max=40"`
 }
 
-func (h *UserHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	switch {
-	case r.Method == http.MethodPost && r.URL.Path == "/users":
-		h.Create(w, r)
-	case r.Method == http.MethodGet && r.URL.Path == "/users":
-		h.GetAll(w, r)
-	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/users/username/"):
-		h.GetByUsername(w, r)
-	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/users/") && !strings.Contains(r.URL.Path, "/tasks"):
-		h.GetByID(w, r)
-	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/users/"):
-		h.Update(w, r)
-	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/users/"):
-		h.Delete(w, r)
-	default:
-		common.ErrorJSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+// Routes lists the endpoints served by the handler
+func (h *UserHandler) Routes() []router.Route {
+	return []router.Route{
+		{Method: http.MethodPost, Pattern: "/users", Handler: h.Create},
+		{Method: http.MethodGet, Pattern: "/users", Handler: h.GetAll},
+		{Method: http.MethodGet, Pattern: "/users/username/{username}", Handler: h.GetByUsername},
+		{Method: http.MethodGet, Pattern: "/users/{id}", Handler: h.GetByID},
+		{Method: http.MethodPatch, Pattern: "/users/{id}", Handler: h.Update},
+		{Method: http.MethodDelete, Pattern: "/users/{id}", Handler: h.Delete},
+		{Method: http.MethodPost, Pattern: "/users/{id}/restore", Handler: h.Restore},
 	}
 }
 
+// ServeHTTP serves the routes of the handler on their own
+func (h *UserHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
 func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if !common.ValidateRequestMethod(w, r, http.MethodPost) {
 		return
 	}
 
 	var req CreateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := common.DecodeJSON(r, &req); err != nil {
 		common.HandleError(w, err)
 		return
 	}
@@ -74,7 +76,8 @@ func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	common.JSONResponse(w, http.StatusCreated, user)
+	w.Header().Set("ETag", common.VersionETag(user.Version))
+	common.JSONResponse(w, r, http.StatusCreated, user)
 }
 
 func (h *UserHandler) GetAll(w http.ResponseWriter, r *http.Request) {
@@ -88,7 +91,7 @@ func (h *UserHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	common.JSONResponse(w, http.StatusOK, users)
+	common.ConditionalJSONResponse(w, r, users)
 }
 
 func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
@@ -96,7 +99,7 @@ func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := common.ExtractIDFromPath(r.URL.Path, "/users/")
+	id, err := common.PathID(r, "id")
 	if err != nil {
 		common.HandleError(w, common.ErrInvalidID)
 		return
@@ -113,7 +116,11 @@ func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	common.JSONResponse(w, http.StatusOK, user)
+	if common.CheckNotModified(w, r, common.VersionETag(user.Version), user.UpdatedAt) {
+		return
+	}
+
+	common.JSONResponse(w, r, http.StatusOK, user)
 }
 
 func (h *UserHandler) GetByUsername(w http.ResponseWriter, r *http.Request) {
@@ -121,7 +128,7 @@ func (h *UserHandler) GetByUsername(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	username := strings.TrimPrefix(r.URL.Path, "/users/username/")
+	username := r.PathValue("username")
 	if username == "" {
 		common.HandleError(w, common.ErrInvalidID)
 		return
@@ -138,7 +145,11 @@ func (h *UserHandler) GetByUsername(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	common.JSONResponse(w, http.StatusOK, user)
+	if common.CheckNotModified(w, r, common.VersionETag(user.Version), user.UpdatedAt) {
+		return
+	}
+
+	common.JSONResponse(w, r, http.StatusOK, user)
 }
 
 func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -146,7 +157,7 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := common.ExtractIDFromPath(r.URL.Path, "/users/")
+	id, err := common.PathID(r, "id")
 	if err != nil {
 		common.HandleError(w, common.ErrInvalidID)
 		return
@@ -163,8 +174,13 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := common.CheckIfMatch(r, existingUser.Version); err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
 	var req UpdateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := common.DecodeJSON(r, &req); err != nil {
 		common.HandleError(w, err)
 		return
 	}
@@ -183,7 +199,7 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.Username == nil && req.Email == nil && req.FirstName == nil && req.LastName == nil {
-		common.ErrorJSONResponse(w, http.StatusBadRequest, "at least one field must be provided for update")
+		common.HandleError(w, common.ErrNoUpdateFields)
 		return
 	}
 
@@ -192,7 +208,8 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	common.JSONResponse(w, http.StatusOK, existingUser)
+	w.Header().Set("ETag", common.VersionETag(existingUser.Version))
+	common.JSONResponse(w, r, http.StatusOK, existingUser)
 }
 
 func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -200,16 +217,64 @@ func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := common.ExtractIDFromPath(r.URL.Path, "/users/")
+	id, err := common.PathID(r, "id")
 	if err != nil {
 		common.HandleError(w, common.ErrInvalidID)
 		return
 	}
 
-	if err := h.repo.Delete(r.Context(), id); err != nil {
+	// Without If-Match the user is deleted whatever its version
+	var version int64
+	if r.Header.Get("If-Match") != "" {
+		user, err := h.repo.GetByID(r.Context(), id)
+		if err != nil {
+			common.HandleError(w, err)
+			return
+		}
+
+		if user == nil {
+			common.HandleError(w, common.ErrNotFound)
+			return
+		}
+
+		if err := common.CheckIfMatch(r, user.Version); err != nil {
+			common.HandleError(w, err)
+			return
+		}
+		version = user.Version
+	}
+
+	if err := h.repo.Delete(r.Context(), id, version); err != nil {
 		common.HandleError(w, err)
 		return
 	}
 
-	common.JSONResponse(w, http.StatusNoContent, nil)
+	common.JSONResponse(w, r, http.StatusNoContent, nil)
+}
+
+// Restore takes a user and the tasks deleted along with them out of the trash
+func (h *UserHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	if !common.ValidateRequestMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	id, err := common.PathID(r, "id")
+	if err != nil {
+		common.HandleError(w, common.ErrInvalidID)
+		return
+	}
+
+	user, err := h.repo.Restore(r.Context(), id)
+	if err != nil {
+		common.HandleError(w, err)
+		return
+	}
+
+	if user == nil {
+		common.HandleError(w, common.ErrNotFound)
+		return
+	}
+
+	w.Header().Set("ETag", common.VersionETag(user.Version))
+	common.JSONResponse(w, r, http.StatusOK, user)
 }
@@ -5,9 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
 	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
@@ -20,7 +23,10 @@ type MockUserRepository struct {
 	getByIDFunc       func(ctx context.Context, id int64) (*entity.User, error)
 	getByUsernameFunc func(ctx context.Context, username string) (*entity.User, error)
 	updateFunc        func(ctx context.Context, user *entity.User) error
-	deleteFunc        func(ctx context.Context, id int64) error
+	deleteFunc        func(ctx context.Context, id int64, version int64) error
+	getDeletedFunc    func(ctx context.Context) ([]entity.User, error)
+	restoreFunc       func(ctx context.Context, id int64) (*entity.User, error)
+	purgeDeletedFunc  func(ctx context.Context, before time.Time) (int64, error)
 }
 
 func (m *MockUserRepository) Create(ctx context.Context, user *entity.User) error {
@@ -43,8 +49,20 @@ func (m *MockUserRepository) Update(ctx context.Context, user *entity.User) erro
 	return m.updateFunc(ctx, user)
 }
 
-func (m *MockUserRepository) Delete(ctx context.Context, id int64) error {
-	return m.deleteFunc(ctx, id)
+func (m *MockUserRepository) Delete(ctx context.Context, id int64, version int64) error {
+	return m.deleteFunc(ctx, id, version)
+}
+
+func (m *MockUserRepository) GetDeleted(ctx context.Context) ([]entity.User, error) {
+	return m.getDeletedFunc(ctx)
+}
+
+func (m *MockUserRepository) Restore(ctx context.Context, id int64) (*entity.User, error) {
+	return m.restoreFunc(ctx, id)
+}
+
+func (m *MockUserRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	return m.purgeDeletedFunc(ctx, before)
 }
 
 func TestUserHandler_Create(t *testing.T) {
@@ -87,6 +105,22 @@ func TestUserHandler_Create(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectedError:  true,
 		},
+		{
+			name: "Error: Username held by a user in the trash",
+			requestBody: CreateUserRequest{
+				Username:  "testuser",
+				Email:     "test@example.com",
+				FirstName: "Test",
+				LastName:  "User",
+			},
+			mockSetup: func(m *MockUserRepository) {
+				m.createFunc = func(ctx context.Context, user *entity.User) error {
+					return fmt.Errorf("%w: username belongs to a user in the trash", common.ErrAlreadyExistsInTrash)
+				}
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -121,6 +155,85 @@ func TestUserHandler_Create(t *testing.T) {
 	}
 }
 
+func TestUserHandler_Create_Validation(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedFields []string
+	}{
+		{
+			name:           "Success: Valid user",
+			body:           `{"username":"testuser","email":"test@example.com","first_name":"Test","last_name":"User"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Success: Names as long as their columns",
+			body:           `{"username":"testuser","email":"test@example.com","first_name":"` + strings.Repeat("あ", 40) + `","last_name":"` + strings.Repeat("い", 40) + `"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Error: All violations are reported together",
+			body:           `{"username":"","email":"not-an-email","first_name":"` + strings.Repeat("あ", 41) + `"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"username", "email", "first_name"},
+		},
+		{
+			name:           "Error: Email longer than the column",
+			body:           `{"username":"testuser","email":"` + strings.Repeat("a", 69) + `@example.com"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"email"},
+		},
+		{
+			name:           "Error: Unknown field",
+			body:           `{"username":"testuser","email":"test@example.com","role":"admin"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedFields: []string{"role"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := false
+			mockRepo := &MockUserRepository{
+				createFunc: func(ctx context.Context, user *entity.User) error {
+					created = true
+					return nil
+				},
+			}
+
+			handler := NewUserHandler(mockRepo)
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.Create(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if created != (tt.expectedFields == nil) {
+				t.Errorf("expected created %v, got %v", tt.expectedFields == nil, created)
+			}
+			if tt.expectedFields == nil {
+				return
+			}
+
+			var problem common.Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(problem.Errors) != len(tt.expectedFields) {
+				t.Fatalf("expected errors for %v, got %+v", tt.expectedFields, problem.Errors)
+			}
+			for i, field := range tt.expectedFields {
+				if problem.Errors[i].Field != field {
+					t.Errorf("expected error %d for %q, got %q", i, field, problem.Errors[i].Field)
+				}
+			}
+		})
+	}
+}
+
 func TestUserHandler_GetByID(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -168,7 +281,7 @@ func TestUserHandler_GetByID(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
 			w := httptest.NewRecorder()
 
-			handler.GetByID(w, req)
+			handler.ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -253,7 +366,7 @@ func TestUserHandler_Update(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPatch, "/users/1", bytes.NewBuffer(body))
 			w := httptest.NewRecorder()
 
-			handler.Update(w, req)
+			handler.ServeHTTP(w, req)
 
 			if w.Code != tt.expectedStatus {
 				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
@@ -275,6 +388,138 @@ func TestUserHandler_Update(t *testing.T) {
 	}
 }
 
+func TestUserHandler_MissingUser(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		ifMatch        string
+		body           string
+		found          bool
+		repoErr        error
+		expectedStatus int
+	}{
+		{
+			name:           "Error: Delete of a nonexistent user",
+			method:         http.MethodDelete,
+			repoErr:        common.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Error: Conditional delete of a nonexistent user",
+			method:         http.MethodDelete,
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Error: Update of a nonexistent user",
+			method:         http.MethodPatch,
+			body:           `{"first_name":"更新後"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Error: User deleted while it was being updated",
+			method:         http.MethodPatch,
+			body:           `{"first_name":"更新後"}`,
+			found:          true,
+			repoErr:        common.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Error: User changed while it was being updated",
+			method:         http.MethodPatch,
+			body:           `{"first_name":"更新後"}`,
+			found:          true,
+			repoErr:        common.ErrPreconditionFailed,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockUserRepository{
+				getByIDFunc: func(ctx context.Context, id int64) (*entity.User, error) {
+					if !tt.found {
+						return nil, nil
+					}
+					return &entity.User{ID: id, Username: "testuser", Email: "test@example.com", Version: 1}, nil
+				},
+				updateFunc: func(ctx context.Context, user *entity.User) error {
+					return tt.repoErr
+				},
+				deleteFunc: func(ctx context.Context, id int64, version int64) error {
+					return tt.repoErr
+				},
+			}
+
+			handler := NewUserHandler(mockRepo)
+			req := httptest.NewRequest(tt.method, "/users/1", bytes.NewBufferString(tt.body))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUserHandler_Restore(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockSetup      func(*MockUserRepository)
+		expectedStatus int
+	}{
+		{
+			name: "Success: User is restored from trash",
+			mockSetup: func(m *MockUserRepository) {
+				m.restoreFunc = func(ctx context.Context, id int64) (*entity.User, error) {
+					return &entity.User{ID: id, Username: "testuser"}, nil
+				}
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Error: User is not in trash",
+			mockSetup: func(m *MockUserRepository) {
+				m.restoreFunc = func(ctx context.Context, id int64) (*entity.User, error) {
+					return nil, nil
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Error: Repository error",
+			mockSetup: func(m *MockUserRepository) {
+				m.restoreFunc = func(ctx context.Context, id int64) (*entity.User, error) {
+					return nil, errors.New("database error")
+				}
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockUserRepository{}
+			tt.mockSetup(mockRepo)
+
+			handler := NewUserHandler(mockRepo)
+			req := httptest.NewRequest(http.MethodPost, "/users/1/restore", nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
 // Helper function
 func stringPtr(s string) *string {
 	return &s
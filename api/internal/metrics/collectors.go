@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// RegisterDBStats exposes the statistics of the connection pool of db, labeled with the database name
+func RegisterDBStats(r prometheus.Registerer, db *sql.DB, name string) {
+	r.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterTaskStatus exposes the number of tasks outside the trash by status, counted on every scrape
+func RegisterTaskStatus(r prometheus.Registerer, taskRepo repository.TaskRepository) {
+	r.MustRegister(&taskStatusCollector{
+		repo: taskRepo,
+		desc: prometheus.NewDesc("tasks", "Number of tasks outside the trash by status.", []string{"status"}, nil),
+	})
+}
+
+// countTimeout bounds the query behind the task status gauge, as scrapes carry no deadline of their own
+const countTimeout = 10 * time.Second
+
+type taskStatusCollector struct {
+	repo repository.TaskRepository
+	desc *prometheus.Desc
+}
+
+func (c *taskStatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *taskStatusCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	counts, err := c.repo.CountByStatus(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for _, status := range entity.TaskStatuses {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts[status]), string(status))
+	}
+}
+
+// RepositoryObserver records the duration of every repository call. It implements
+// repository.Observer.
+type RepositoryObserver struct {
+	duration *prometheus.HistogramVec
+}
+
+func NewRepositoryObserver(r prometheus.Registerer) *RepositoryObserver {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "repository_call_duration_seconds",
+		Help: "Duration of repository calls, including every query they run.",
+	}, []string{"repository", "method", "outcome"})
+	r.MustRegister(duration)
+	return &RepositoryObserver{duration: duration}
+}
+
+func (o *RepositoryObserver) Observe(ctx context.Context, repository, method string) (context.Context, func(err error)) {
+	start := time.Now()
+	return ctx, func(err error) {
+		outcome := "ok"
+		if err != nil {
+			outcome = "error"
+		}
+		o.duration.WithLabelValues(repository, method, outcome).Observe(time.Since(start).Seconds())
+	}
+}
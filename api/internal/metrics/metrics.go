@@ -0,0 +1,39 @@
+// Package metrics collects the metrics of the process with the Prometheus client. They are served on
+// a listener of their own so that they are not exposed with the API.
+package metrics
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// NewRegistry returns a registry holding the Go runtime and process metrics
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+// Handler answers scrapes of the registry. A collector that fails is logged and left out, so that one
+// failing source does not hide the others.
+func Handler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorLog:      errorLogger{},
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// errorLogger passes the errors of the scrape handler on to the default logger
+type errorLogger struct{}
+
+func (errorLogger) Println(v ...interface{}) {
+	slog.Error("failed to collect metrics", slog.String("error", fmt.Sprint(v...)))
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// failingCollector stands for a source whose values cannot be read, such as a closed database
+type failingCollector struct {
+	desc *prometheus.Desc
+}
+
+func (c failingCollector) Describe(ch chan<- *prometheus.Desc) { ch <- c.desc }
+
+func (c failingCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.NewInvalidMetric(c.desc, errors.New("database is closed"))
+}
+
+func TestHandler(t *testing.T) {
+	registry := NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "http_requests_total", Help: "Number of HTTP requests served."}, []string{"route"})
+	registry.MustRegister(requests, failingCollector{desc: prometheus.NewDesc("tasks", "Number of tasks outside the trash by status.", nil, nil)})
+	requests.WithLabelValues("/tasks/{id}").Add(2)
+
+	w := httptest.NewRecorder()
+	Handler(registry).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("expected the text format, got %q", w.Header().Get("Content-Type"))
+	}
+	for _, expected := range []string{
+		`http_requests_total{route="/tasks/{id}"} 2`,
+		`go_goroutines `,
+	} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("expected %s in:\n%s", expected, w.Body.String())
+		}
+	}
+	if strings.Contains(w.Body.String(), "\ntasks") {
+		t.Errorf("expected the failing collector to be left out, got:\n%s", w.Body.String())
+	}
+}
+
+func TestRepositoryObserver(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	observer := NewRepositoryObserver(registry)
+
+	for _, err := range []error{nil, nil, errors.New("database error")} {
+		_, done := observer.Observe(context.Background(), "task", "GetByID")
+		done(err)
+	}
+
+	if n := testutil.CollectAndCount(observer.duration); n != 2 {
+		t.Errorf("expected a series per outcome, got %d", n)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	counts := map[string]uint64{}
+	for _, m := range families[0].GetMetric() {
+		for _, label := range m.GetLabel() {
+			if label.GetName() == "outcome" {
+				counts[label.GetValue()] = m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	if counts["ok"] != 2 || counts["error"] != 1 {
+		t.Errorf("expected 2 ok and 1 error calls, got %v", counts)
+	}
+}
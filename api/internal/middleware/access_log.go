@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+// AccessLog logs one structured line per request with its method, route pattern, status, response
+// size and latency. The route is the pattern that matched, such as /tasks/{id}, so that lines for
+// the same endpoint can be grouped; it is empty when no route matched.
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		r = r.WithContext(common.WithRoute(r.Context()))
+
+		defer func() {
+			status := sw.status
+			if status == 0 {
+				// A handler that panicked before writing is answered by Recover, or not at all
+				status = http.StatusInternalServerError
+			}
+			common.Logger(r.Context()).LogAttrs(r.Context(), slog.LevelInfo, "request",
+				slog.String("method", r.Method),
+				slog.String("route", common.RouteFromContext(r.Context())),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int64("bytes", sw.bytes),
+				slog.Duration("latency", time.Since(start)),
+			)
+		}()
+
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// statusWriter remembers the status and the size of a response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
+)
+
+// captureLogs sends the default logger to a buffer in JSON for the duration of a test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestAccessLog(t *testing.T) {
+	rt := router.New(router.Route{Method: http.MethodGet, Pattern: "/tasks/{id}", Handler: func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":1}`))
+	}})
+	handler := Chain{RequestID, Actor, AccessLog}.Then(rt)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedRoute  string
+		expectedStatus float64
+	}{
+		{name: "Matched route", path: "/tasks/1", expectedRoute: "/tasks/{id}", expectedStatus: http.StatusOK},
+		{name: "Unknown path", path: "/unknown", expectedRoute: "", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-Request-ID", "req-1")
+			req.Header.Set(ActorHeader, "42")
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to decode log line %q: %v", logs.String(), err)
+			}
+			if entry["method"] != http.MethodGet {
+				t.Errorf("expected method GET, got %v", entry["method"])
+			}
+			if entry["route"] != tt.expectedRoute {
+				t.Errorf("expected route %q, got %v", tt.expectedRoute, entry["route"])
+			}
+			if entry["status"] != tt.expectedStatus {
+				t.Errorf("expected status %v, got %v", tt.expectedStatus, entry["status"])
+			}
+			if entry["bytes"] != float64(w.Body.Len()) {
+				t.Errorf("expected bytes %d, got %v", w.Body.Len(), entry["bytes"])
+			}
+			if entry["request_id"] != "req-1" {
+				t.Errorf("expected request_id req-1, got %v", entry["request_id"])
+			}
+			if entry["user_id"] != "42" {
+				t.Errorf("expected user_id 42, got %v", entry["user_id"])
+			}
+			if _, ok := entry["latency"]; !ok {
+				t.Error("expected latency to be logged")
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+// ActorHeader identifies the user making the request
+const ActorHeader = "X-User-ID"
+
+// Actor stores the requesting user from the X-User-ID header in the request context and adds it to
+// the request logger
+func Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actor := r.Header.Get(ActorHeader); actor != "" {
+			ctx := common.WithActor(r.Context(), actor)
+			ctx = common.WithLogger(ctx, common.Logger(ctx).With(slog.String("user_id", actor)))
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+type BodyLimitConfig struct {
+	maxBodySize int64
+}
+
+func NewBodyLimitConfig(cfg *config.Config) *BodyLimitConfig {
+	return &BodyLimitConfig{maxBodySize: cfg.MaxBodySize}
+}
+
+// BodyLimit cuts request bodies off after the configured size. Requests announcing a larger body are
+// rejected with 413 right away; other bodies fail with 413 as soon as they are read past the limit.
+func (c *BodyLimitConfig) BodyLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > c.maxBodySize {
+			common.HandleError(w, &http.MaxBytesError{Limit: c.maxBodySize})
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, c.maxBodySize)
+		next.ServeHTTP(w, r)
+	})
+}
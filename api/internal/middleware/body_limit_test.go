@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+func TestBodyLimit(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		chunked        bool
+		expectedStatus int
+	}{
+		{name: "Success: Body within the limit", body: `{"title":"買い物"}`, expectedStatus: http.StatusCreated},
+		{name: "Error: Announced body over the limit", body: `{"title":"` + strings.Repeat("あ", 20) + `"}`, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "Error: Chunked body over the limit", body: `{"title":"` + strings.Repeat("あ", 20) + `"}`, chunked: true, expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var v struct {
+					Title string `json:"title"`
+				}
+				if err := common.DecodeJSON(r, &v); err != nil {
+					common.HandleError(w, err)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+			})
+			handler := NewBodyLimitConfig(&config.Config{MaxBodySize: 32}).BodyLimit(next)
+
+			req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.Body = io.NopCloser(strings.NewReader(tt.body))
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusRequestEntityTooLarge {
+				return
+			}
+			var problem common.Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if problem.Code != "request_too_large" || !strings.Contains(problem.Detail, "32 bytes") {
+				t.Errorf("expected the limit in the problem, got %+v", problem)
+			}
+		})
+	}
+}
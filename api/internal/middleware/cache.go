@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+)
+
+type cacheRule struct {
+	segments []string
+	value    string
+}
+
+type CacheControlConfig struct {
+	rules []cacheRule
+}
+
+// NewCacheControlConfig builds the Cache-Control rules. A {param} segment in a route matches any
+// single path segment; when several routes match, the one with the most literal segments wins.
+func NewCacheControlConfig(cfg *config.Config) *CacheControlConfig {
+	c := &CacheControlConfig{}
+	for route, value := range cfg.CacheControl {
+		c.rules = append(c.rules, cacheRule{segments: splitPath(route), value: value})
+	}
+	sort.Slice(c.rules, func(i, j int) bool {
+		li, lj := literalSegments(c.rules[i].segments), literalSegments(c.rules[j].segments)
+		if li != lj {
+			return li > lj
+		}
+		return strings.Join(c.rules[i].segments, "/") < strings.Join(c.rules[j].segments, "/")
+	})
+	return c
+}
+
+// CacheControl sets the configured Cache-Control header on successful GET and HEAD responses,
+// including 304 Not Modified. Errors are never marked cacheable.
+func (c *CacheControlConfig) CacheControl(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		value := c.lookup(r.URL.Path)
+		if value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&cacheControlWriter{ResponseWriter: w, value: value}, r)
+	})
+}
+
+func (c *CacheControlConfig) lookup(path string) string {
+	segments := splitPath(path)
+	for _, rule := range c.rules {
+		if matchSegments(rule.segments, segments) {
+			return rule.value
+		}
+	}
+	return ""
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func literalSegments(segments []string) int {
+	n := 0
+	for _, segment := range segments {
+		if !strings.HasPrefix(segment, "{") {
+			n++
+		}
+	}
+	return n
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+	for i, segment := range pattern {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheControlWriter adds the header once the status is known
+type cacheControlWriter struct {
+	http.ResponseWriter
+	value       string
+	wroteHeader bool
+}
+
+func (w *cacheControlWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if (status == http.StatusOK || status == http.StatusNotModified) && w.Header().Get("Cache-Control") == "" {
+			w.Header().Set("Cache-Control", w.value)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheControlWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+)
+
+func TestCacheControl(t *testing.T) {
+	cfg := &config.Config{CacheControl: map[string]string{
+		"/tasks":            "private, max-age=10",
+		"/tasks/{id}":       "private, no-cache",
+		"/users/{id}/tasks": "no-store",
+		"/users/{id}/{sub}": "public",
+	}}
+	cacheConfig := NewCacheControlConfig(cfg)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		status   int
+		expected string
+	}{
+		{name: "Exact route", method: http.MethodGet, path: "/tasks", status: http.StatusOK, expected: "private, max-age=10"},
+		{name: "Route with a parameter", method: http.MethodGet, path: "/tasks/1", status: http.StatusOK, expected: "private, no-cache"},
+		{name: "Most literal route wins", method: http.MethodGet, path: "/users/1/tasks", status: http.StatusOK, expected: "no-store"},
+		{name: "Not modified", method: http.MethodGet, path: "/tasks/1", status: http.StatusNotModified, expected: "private, no-cache"},
+		{name: "Errors are not cacheable", method: http.MethodGet, path: "/tasks/1", status: http.StatusNotFound, expected: ""},
+		{name: "Writes are not cacheable", method: http.MethodPatch, path: "/tasks/1", status: http.StatusOK, expected: ""},
+		{name: "Unconfigured route", method: http.MethodGet, path: "/projects", status: http.StatusOK, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			cacheConfig.CacheControl(next).ServeHTTP(w, req)
+
+			if got := w.Header().Get("Cache-Control"); got != tt.expected {
+				t.Errorf("expected Cache-Control %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
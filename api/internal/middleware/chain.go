@@ -0,0 +1,14 @@
+package middleware
+
+import "net/http"
+
+// Chain is a list of middleware applied in order, so the first one is the outermost
+type Chain []func(http.Handler) http.Handler
+
+// Then wraps handler with every middleware of the chain
+func (c Chain) Then(handler http.Handler) http.Handler {
+	for i := len(c) - 1; i >= 0; i-- {
+		handler = c[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+)
+
+type CompressConfig struct {
+	minSize int
+}
+
+func NewCompressConfig(cfg *config.Config) *CompressConfig {
+	return &CompressConfig{minSize: int(cfg.CompressMinSize)}
+}
+
+// Compress compresses responses with gzip or deflate, whichever Accept-Encoding prefers. Responses
+// are buffered until they reach the size threshold, so that small bodies are sent as they are, and a
+// handler that flushes starts compressing right away. The ETag of a compressed response is made weak,
+// as the encoded body differs from the one it was computed for.
+func (c *CompressConfig) Compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := acceptedEncoding(r.Header.Values("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: c.minSize}
+		// Not deferred: after a panic the buffered response is dropped so that Recover can answer 500
+		next.ServeHTTP(cw, r)
+		cw.Close()
+	})
+}
+
+// encodings lists the supported content codings in the order they are preferred on a tie
+var encodings = []string{"gzip", "deflate"}
+
+// acceptedEncoding picks the supported coding the client weighs highest, or "" for none
+func acceptedEncoding(headers []string) string {
+	qualities := make(map[string]float64)
+	for _, entry := range strings.Split(strings.Join(headers, ","), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		qualities[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range encodings {
+		q, ok := qualities[encoding]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+var (
+	gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}
+	zlibWriters = sync.Pool{New: func() interface{} { return zlib.NewWriter(io.Discard) }}
+)
+
+// compressWriter holds the response back until it knows whether it is worth compressing
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+	zl      *zlib.Writer
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.status != 0 || cw.decided {
+		return
+	}
+	cw.status = status
+	// Informational responses go out at once, and some responses never have a body
+	if status < http.StatusOK {
+		cw.status = 0
+		cw.ResponseWriter.WriteHeader(status)
+	} else if status == http.StatusNoContent || status == http.StatusNotModified {
+		cw.start(false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) >= cw.minSize {
+			if err := cw.start(true); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+	return cw.body().Write(p)
+}
+
+// Flush sends what has been written so far, compressing it when the response is streamed
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.WriteHeader(http.StatusOK)
+		}
+		cw.start(true)
+	}
+	if cw.gz != nil {
+		cw.gz.Flush()
+	}
+	if cw.zl != nil {
+		cw.zl.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Close sends a response that stayed under the threshold and finishes a compressed one
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if cw.status == 0 {
+			// Nothing was written, which net/http answers with an empty 200
+			return nil
+		}
+		if err := cw.start(false); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if cw.gz != nil {
+		err = cw.gz.Close()
+		gzipWriters.Put(cw.gz)
+		cw.gz = nil
+	}
+	if cw.zl != nil {
+		err = cw.zl.Close()
+		zlibWriters.Put(cw.zl)
+		cw.zl = nil
+	}
+	return err
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// start sends the status and the buffered body, compressing from now on when compress is set and
+// the handler has not encoded the body itself
+func (cw *compressWriter) start(compress bool) error {
+	cw.decided = true
+	header := cw.ResponseWriter.Header()
+	if compress && header.Get("Content-Encoding") == "" {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		switch cw.encoding {
+		case "gzip":
+			cw.gz = gzipWriters.Get().(*gzip.Writer)
+			cw.gz.Reset(cw.ResponseWriter)
+		default:
+			cw.zl = zlibWriters.Get().(*zlib.Writer)
+			cw.zl.Reset(cw.ResponseWriter)
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := cw.body().Write(buf)
+	return err
+}
+
+// body is where the response body goes once compression has been decided on
+func (cw *compressWriter) body() io.Writer {
+	switch {
+	case cw.gz != nil:
+		return cw.gz
+	case cw.zl != nil:
+		return cw.zl
+	default:
+		return cw.ResponseWriter
+	}
+}
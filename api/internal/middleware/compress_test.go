@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+)
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"title":"買い物"}`, 100)
+
+	tests := []struct {
+		name             string
+		acceptEncoding   string
+		method           string
+		status           int
+		body             string
+		contentEncoding  string
+		etag             string
+		flush            bool
+		expectedEncoding string
+		expectedETag     string
+	}{
+		{name: "Success: gzip", acceptEncoding: "gzip, deflate", body: large, expectedEncoding: "gzip"},
+		{name: "Success: deflate preferred", acceptEncoding: "gzip;q=0.5, deflate", body: large, expectedEncoding: "deflate"},
+		{name: "Success: gzip refused", acceptEncoding: "gzip;q=0, *", body: large, expectedEncoding: "deflate"},
+		{name: "Success: Any coding", acceptEncoding: "*", body: large, expectedEncoding: "gzip"},
+		{name: "Success: Identity only", acceptEncoding: "identity", body: large},
+		{name: "Success: No Accept-Encoding", body: large},
+		{name: "Success: Under the threshold", acceptEncoding: "gzip", body: `{"id":1}`},
+		{name: "Success: Streamed under the threshold", acceptEncoding: "gzip", body: `{"id":1}`, flush: true, expectedEncoding: "gzip"},
+		{name: "Success: No content", acceptEncoding: "gzip", status: http.StatusNoContent},
+		{name: "Success: HEAD", acceptEncoding: "gzip", method: http.MethodHead},
+		{name: "Success: Already encoded", acceptEncoding: "gzip", body: large, contentEncoding: "br", expectedEncoding: "br"},
+		{name: "Success: ETag weakened", acceptEncoding: "gzip", body: large, etag: `"3"`, expectedEncoding: "gzip", expectedETag: `W/"3"`},
+		{name: "Success: Weak ETag kept", acceptEncoding: "gzip", body: large, etag: `W/"3"`, expectedEncoding: "gzip", expectedETag: `W/"3"`},
+		{name: "Success: ETag of an uncompressed response", acceptEncoding: "gzip", body: `{"id":1}`, etag: `"3"`, expectedETag: `"3"`},
+		{name: "Success: ETag of an already encoded response", acceptEncoding: "gzip", body: large, contentEncoding: "br", etag: `"3"`, expectedEncoding: "br", expectedETag: `"3"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if tt.contentEncoding != "" {
+					w.Header().Set("Content-Encoding", tt.contentEncoding)
+				}
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				io.WriteString(w, tt.body)
+				if tt.flush {
+					http.NewResponseController(w).Flush()
+				}
+			})
+			handler := NewCompressConfig(&config.Config{CompressMinSize: 1024}).Compress(next)
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/tasks", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.expectedEncoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", tt.expectedEncoding, got)
+			}
+			if got := w.Header().Get("ETag"); got != tt.expectedETag {
+				t.Errorf("expected ETag %q, got %q", tt.expectedETag, got)
+			}
+			if w.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("expected Vary: Accept-Encoding, got %q", w.Header().Get("Vary"))
+			}
+			if tt.status != 0 && w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+
+			var body io.Reader = w.Body
+			switch tt.expectedEncoding {
+			case "gzip":
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatalf("failed to read gzip: %v", err)
+				}
+				body = zr
+			case "deflate":
+				zr, err := zlib.NewReader(w.Body)
+				if err != nil {
+					t.Fatalf("failed to read deflate: %v", err)
+				}
+				body = zr
+			}
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("failed to decompress: %v", err)
+			}
+			if string(got) != tt.body {
+				t.Errorf("expected body of %d bytes, got %d bytes", len(tt.body), len(got))
+			}
+		})
+	}
+}
+
+func TestCompress_Panic(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[{"title":"途中まで"`)
+		panic("タスクの取得に失敗")
+	})
+	handler := Chain{Recover, NewCompressConfig(&config.Config{CompressMinSize: 1024}).Compress}.Then(next)
+
+	captureLogs(t)
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if strings.Contains(w.Body.String(), "途中まで") {
+		t.Errorf("expected the partial response to be dropped, got %q", w.Body.String())
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
 )
 
 type CORSConfig struct {
@@ -30,13 +31,14 @@ func (c *CORSConfig) CORS(next http.Handler) http.Handler {
 				}
 			}
 			if !allowed {
-				http.Error(w, "Not allowed origin", http.StatusForbidden)
+				common.ErrorJSONResponse(w, http.StatusForbidden, "Not allowed origin")
 				return
 			}
 		}
 
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User-ID, If-Match, If-None-Match, If-Modified-Since, Idempotency-Key, X-Request-ID, traceparent, tracestate")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Last-Modified, Idempotent-Replayed, X-Request-ID, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After")
 
 		// For OPTIONS requests, terminate processing here
 		if r.Method == "OPTIONS" {
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+const (
+	// IdempotencyKeyHeader lets clients retry a POST without creating the resource twice
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response that was replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+type IdempotencyConfig struct {
+	store repository.IdempotencyRepository
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+func NewIdempotencyConfig(cfg *config.Config, store repository.IdempotencyRepository) *IdempotencyConfig {
+	return &IdempotencyConfig{
+		store: store,
+		ttl:   cfg.IdempotencyTTL,
+		now:   time.Now,
+	}
+}
+
+// Idempotency stores the response of a POST request sent with an Idempotency-Key header and replays
+// it when the request is retried with the same key. Keys are scoped to the actor. Reusing a key with
+// a different request is rejected with 422, and a retry that arrives while the original request is
+// still running gets 409. Server errors and 429 responses of the rate limiter are not stored so that
+// the request can be retried.
+func (c *IdempotencyConfig) Idempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if r.Method != http.MethodPost || key == "" || c.ttl <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			common.HandleError(w, common.ErrInvalidIdempotencyKey)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			common.HandleError(w, err)
+			return
+		}
+		if err != nil {
+			common.HandleError(w, common.ErrInvalidRequestBody)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := c.now()
+		record := &entity.IdempotencyRecord{
+			Actor:       common.ActorFromContext(r.Context()),
+			Key:         key,
+			Fingerprint: fingerprint(r, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(c.ttl),
+		}
+
+		existing, err := c.store.Reserve(r.Context(), record)
+		if err != nil {
+			common.HandleError(w, err)
+			return
+		}
+		if existing != nil {
+			replay(w, record, existing)
+			return
+		}
+
+		recorder := &idempotencyWriter{ResponseWriter: w}
+		// The outcome is stored even if the client has gone away, since that is when it retries
+		ctx := context.WithoutCancel(r.Context())
+		defer func() {
+			if recorder.status == 0 || recorder.status == http.StatusTooManyRequests || recorder.status >= http.StatusInternalServerError {
+				if err := c.store.Release(ctx, record.Actor, record.Key); err != nil {
+					common.Logger(ctx).Error("failed to release idempotency key", slog.Any("error", err))
+				}
+				return
+			}
+
+			record.StatusCode = recorder.status
+			record.ContentType = recorder.Header().Get("Content-Type")
+			record.ETag = recorder.Header().Get("ETag")
+			record.Body = recorder.body.Bytes()
+			if err := c.store.Complete(ctx, record); err != nil {
+				common.Logger(ctx).Error("failed to store idempotent response", slog.Any("error", err))
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// replay answers a retried request from the record of the original one
+func replay(w http.ResponseWriter, record, existing *entity.IdempotencyRecord) {
+	if existing.Fingerprint != record.Fingerprint {
+		common.HandleError(w, common.ErrIdempotencyKeyReused)
+		return
+	}
+	if !existing.Completed() {
+		w.Header().Set("Retry-After", "1")
+		common.HandleError(w, common.ErrIdempotencyInProgress)
+		return
+	}
+
+	if existing.ContentType != "" {
+		w.Header().Set("Content-Type", existing.ContentType)
+	}
+	// The ETag lets the client make conditional requests on the created resource
+	if existing.ETag != "" {
+		w.Header().Set("ETag", existing.ETag)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.Header().Set("Content-Length", strconv.Itoa(len(existing.Body)))
+	w.WriteHeader(existing.StatusCode)
+	w.Write(existing.Body)
+}
+
+// fingerprint identifies the request a key was first used with
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyWriter keeps a copy of the response while writing it to the client
+type idempotencyWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *idempotencyWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
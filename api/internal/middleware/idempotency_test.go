@@ -0,0 +1,288 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/internal/ratelimit"
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+// memoryIdempotencyStore keeps the records in memory
+type memoryIdempotencyStore struct {
+	records  map[string]*entity.IdempotencyRecord
+	reserved int
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, record *entity.IdempotencyRecord) (*entity.IdempotencyRecord, error) {
+	s.reserved++
+	if existing, ok := s.records[record.Actor+"/"+record.Key]; ok && !existing.ExpiresAt.Before(record.CreatedAt) {
+		return existing, nil
+	}
+	stored := *record
+	s.records[record.Actor+"/"+record.Key] = &stored
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, record *entity.IdempotencyRecord) error {
+	stored := *record
+	s.records[record.Actor+"/"+record.Key] = &stored
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, actor, key string) error {
+	delete(s.records, actor+"/"+key)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotency(t *testing.T) {
+	type request struct {
+		actor string
+		key   string
+		body  string
+	}
+
+	tests := []struct {
+		name             string
+		first            request
+		retry            request
+		status           int
+		inProgress       bool
+		expired          bool
+		expectedStatus   int
+		expectedCalls    int
+		expectedReplayed bool
+	}{
+		{
+			name:             "Retry replays the stored response",
+			first:            request{key: "key-1", body: `{"title":"タスク1"}`},
+			retry:            request{key: "key-1", body: `{"title":"タスク1"}`},
+			status:           http.StatusCreated,
+			expectedStatus:   http.StatusCreated,
+			expectedCalls:    1,
+			expectedReplayed: true,
+		},
+		{
+			name:           "Reusing a key with a different body is rejected",
+			first:          request{key: "key-1", body: `{"title":"タスク1"}`},
+			retry:          request{key: "key-1", body: `{"title":"タスク2"}`},
+			status:         http.StatusCreated,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCalls:  1,
+		},
+		{
+			name:           "Retry while the original request is running conflicts",
+			first:          request{key: "key-1", body: `{"title":"タスク1"}`},
+			retry:          request{key: "key-1", body: `{"title":"タスク1"}`},
+			status:         http.StatusCreated,
+			inProgress:     true,
+			expectedStatus: http.StatusConflict,
+			expectedCalls:  1,
+		},
+		{
+			name:           "Server errors are not stored",
+			first:          request{key: "key-1", body: `{"title":"タスク1"}`},
+			retry:          request{key: "key-1", body: `{"title":"タスク1"}`},
+			status:         http.StatusInternalServerError,
+			expectedStatus: http.StatusInternalServerError,
+			expectedCalls:  2,
+		},
+		{
+			name:           "Rate limited requests are not stored",
+			first:          request{key: "key-1", body: `{"title":"タスク1"}`},
+			retry:          request{key: "key-1", body: `{"title":"タスク1"}`},
+			status:         http.StatusTooManyRequests,
+			expectedStatus: http.StatusTooManyRequests,
+			expectedCalls:  2,
+		},
+		{
+			name:             "Client errors are stored",
+			first:            request{key: "key-1", body: `{"title":""}`},
+			retry:            request{key: "key-1", body: `{"title":""}`},
+			status:           http.StatusBadRequest,
+			expectedStatus:   http.StatusBadRequest,
+			expectedCalls:    1,
+			expectedReplayed: true,
+		},
+		{
+			name:           "Keys are scoped to the actor",
+			first:          request{actor: "1", key: "key-1", body: `{"title":"タスク1"}`},
+			retry:          request{actor: "2", key: "key-1", body: `{"title":"タスク2"}`},
+			status:         http.StatusCreated,
+			expectedStatus: http.StatusCreated,
+			expectedCalls:  2,
+		},
+		{
+			name:           "Expired keys can be reused",
+			first:          request{key: "key-1", body: `{"title":"タスク1"}`},
+			retry:          request{key: "key-1", body: `{"title":"タスク2"}`},
+			status:         http.StatusCreated,
+			expired:        true,
+			expectedStatus: http.StatusCreated,
+			expectedCalls:  2,
+		},
+		{
+			name:           "Requests without a key are not deduplicated",
+			first:          request{body: `{"title":"タスク1"}`},
+			retry:          request{body: `{"title":"タスク1"}`},
+			status:         http.StatusCreated,
+			expectedStatus: http.StatusCreated,
+			expectedCalls:  2,
+		},
+		{
+			name:           "Overlong keys are rejected",
+			first:          request{body: `{"title":"タスク1"}`},
+			retry:          request{key: strings.Repeat("a", 256), body: `{"title":"タスク1"}`},
+			status:         http.StatusCreated,
+			expectedStatus: http.StatusBadRequest,
+			expectedCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryIdempotencyStore{records: map[string]*entity.IdempotencyRecord{}}
+			idempotencyConfig := NewIdempotencyConfig(&config.Config{IdempotencyTTL: time.Hour}, store)
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			idempotencyConfig.now = func() time.Time { return now }
+
+			calls := 0
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body, _ := io.ReadAll(r.Body)
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("ETag", `"1"`)
+				w.WriteHeader(tt.status)
+				w.Write(body)
+			})
+			handler := Actor(idempotencyConfig.Idempotency(next))
+
+			send := func(req request) *httptest.ResponseRecorder {
+				r := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(req.body))
+				if req.actor != "" {
+					r.Header.Set(ActorHeader, req.actor)
+				}
+				if req.key != "" {
+					r.Header.Set(IdempotencyKeyHeader, req.key)
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+				return w
+			}
+
+			first := send(tt.first)
+			if tt.inProgress {
+				for _, record := range store.records {
+					record.StatusCode = 0
+				}
+			}
+			if tt.expired {
+				now = now.Add(2 * time.Hour)
+			}
+			w := send(tt.retry)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("expected the handler to be called %d times, got %d", tt.expectedCalls, calls)
+			}
+			if replayed := w.Header().Get(IdempotentReplayedHeader) == "true"; replayed != tt.expectedReplayed {
+				t.Errorf("expected replayed %v, got %v", tt.expectedReplayed, replayed)
+			}
+			if tt.expectedReplayed {
+				if w.Body.String() != first.Body.String() {
+					t.Errorf("expected replayed body %q, got %q", first.Body.String(), w.Body.String())
+				}
+				if w.Header().Get("Content-Type") != "application/json" {
+					t.Errorf("expected replayed content type, got %q", w.Header().Get("Content-Type"))
+				}
+				if w.Header().Get("ETag") != `"1"` {
+					t.Errorf("expected replayed ETag, got %q", w.Header().Get("ETag"))
+				}
+			}
+			if w.Code == http.StatusConflict && w.Header().Get("Retry-After") == "" {
+				t.Error("expected Retry-After on a conflicting retry")
+			}
+		})
+	}
+}
+
+func TestIdempotency_RateLimited(t *testing.T) {
+	store := &memoryIdempotencyStore{records: map[string]*entity.IdempotencyRecord{}}
+	cfg := &config.Config{IdempotencyTTL: time.Hour, RateLimit: config.RateLimit{Requests: 1, Period: time.Minute}}
+
+	calls := 0
+	mux := router.New(router.Route{Method: http.MethodPost, Pattern: "/tasks", Handler: func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		common.JSONResponse(w, r, http.StatusCreated, map[string]string{"title": "タスク1"})
+	}})
+	rateLimitConfig := NewRateLimitConfig(cfg, &limitedStore{denied: 1}, mux)
+	handler := Chain{Actor, rateLimitConfig.RateLimit, NewIdempotencyConfig(cfg, store).Idempotency}.Then(mux)
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title":"タスク1"}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the first request to be limited, got status %d", w.Code)
+	}
+	if store.reserved != 0 {
+		t.Errorf("expected the limited request not to reserve its key, got %d reservations", store.reserved)
+	}
+	w := send()
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected the retry to be served, got status %d", w.Code)
+	}
+	if w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Error("expected the retry not to replay the 429")
+	}
+	if calls != 1 {
+		t.Errorf("expected the handler to be called once, got %d", calls)
+	}
+}
+
+// limitedStore denies the given number of requests and allows the rest
+type limitedStore struct {
+	denied int
+}
+
+func (s *limitedStore) Take(ctx context.Context, key string, limit config.RateLimit) (ratelimit.Result, error) {
+	if s.denied > 0 {
+		s.denied--
+		return ratelimit.Result{RetryAfter: time.Second}, nil
+	}
+	return ratelimit.Result{Allowed: true, Remaining: limit.Requests - 1}, nil
+}
+
+func TestIdempotency_IgnoresOtherMethods(t *testing.T) {
+	store := &memoryIdempotencyStore{records: map[string]*entity.IdempotencyRecord{}}
+	idempotencyConfig := NewIdempotencyConfig(&config.Config{IdempotencyTTL: time.Hour}, store)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		common.JSONResponse(w, r, http.StatusOK, nil)
+	})
+
+	req := httptest.NewRequest(http.MethodPatch, "/tasks/1", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	idempotencyConfig.Idempotency(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(store.records) != 0 {
+		t.Errorf("expected no stored records, got %d", len(store.records))
+	}
+}
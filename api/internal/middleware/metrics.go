@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unmatchedRoute labels requests that no route matched, so that scans of random paths share a
+// single series
+const unmatchedRoute = "unmatched"
+
+type MetricsConfig struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func NewMetricsConfig(registry prometheus.Registerer) *MetricsConfig {
+	labels := []string{"method", "route", "status"}
+	c := &MetricsConfig{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of HTTP requests served.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "http_request_duration_seconds",
+			Help: "Latency of HTTP requests.",
+		}, labels),
+	}
+	registry.MustRegister(c.requests, c.duration)
+	return c
+}
+
+// Metrics counts requests and records their latency by method, route pattern and status. Labeling
+// by the pattern, such as /tasks/{id}, rather than the path keeps the number of series bounded.
+func (c *MetricsConfig) Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		r = r.WithContext(common.WithRoute(r.Context()))
+
+		defer func() {
+			status := sw.status
+			if status == 0 {
+				status = http.StatusInternalServerError
+			}
+			route := common.RouteFromContext(r.Context())
+			if route == "" {
+				route = unmatchedRoute
+			}
+
+			labels := []string{r.Method, route, strconv.Itoa(status)}
+			c.requests.WithLabelValues(labels...).Inc()
+			c.duration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		}()
+
+		next.ServeHTTP(sw, r)
+	})
+}
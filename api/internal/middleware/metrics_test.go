@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	config := NewMetricsConfig(prometheus.NewRegistry())
+	rt := router.New(
+		router.Route{Method: http.MethodGet, Pattern: "/tasks/{id}", Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}},
+		router.Route{Method: http.MethodDelete, Pattern: "/tasks/{id}", Handler: func(w http.ResponseWriter, r *http.Request) {
+			panic("タスクの削除に失敗")
+		}},
+	)
+	handler := Chain{config.Metrics, Recover}.Then(rt)
+
+	captureLogs(t)
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/tasks/1", nil),
+		httptest.NewRequest(http.MethodGet, "/tasks/2", nil),
+		httptest.NewRequest(http.MethodDelete, "/tasks/1", nil),
+		httptest.NewRequest(http.MethodGet, "/unknown", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	for _, tt := range []struct {
+		labels   []string
+		expected float64
+	}{
+		{labels: []string{"GET", "/tasks/{id}", "200"}, expected: 2},
+		{labels: []string{"DELETE", "/tasks/{id}", "500"}, expected: 1},
+		{labels: []string{"GET", "unmatched", "404"}, expected: 1},
+	} {
+		if got := testutil.ToFloat64(config.requests.WithLabelValues(tt.labels...)); got != tt.expected {
+			t.Errorf("expected %v requests for %v, got %v", tt.expected, tt.labels, got)
+		}
+	}
+	if n := testutil.CollectAndCount(config.duration); n != 3 {
+		t.Errorf("expected a latency series per method, route and status, got %d", n)
+	}
+}
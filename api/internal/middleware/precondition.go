@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+// RequireIfMatch rejects PATCH and DELETE requests that do not send If-Match with 428 Precondition
+// Required, so that clients cannot overwrite changes they never saw. It is attached to the routes of
+// resources that carry a version ETag.
+func RequireIfMatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method == http.MethodPatch || r.Method == http.MethodDelete) && r.Header.Get("If-Match") == "" {
+			common.HandleError(w, common.ErrPreconditionRequired)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireIfMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		ifMatch        string
+		expectedStatus int
+	}{
+		{name: "PATCH task without If-Match", method: http.MethodPatch, path: "/tasks/1", expectedStatus: http.StatusPreconditionRequired},
+		{name: "DELETE user without If-Match", method: http.MethodDelete, path: "/users/1", expectedStatus: http.StatusPreconditionRequired},
+		{name: "PATCH task with If-Match", method: http.MethodPatch, path: "/tasks/1", ifMatch: `"1"`, expectedStatus: http.StatusOK},
+		{name: "GET task", method: http.MethodGet, path: "/tasks/1", expectedStatus: http.StatusOK},
+		{name: "DELETE user with If-Match", method: http.MethodDelete, path: "/users/1", ifMatch: `"2"`, expectedStatus: http.StatusOK},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			RequireIfMatch(next).ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
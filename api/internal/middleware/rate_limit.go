@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"github.com/kenwoo9y/todo-api-go/api/internal/ratelimit"
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+// Headers describing the limit of the client, see draft-ietf-httpapi-ratelimit-headers
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+)
+
+type RateLimitConfig struct {
+	store          ratelimit.Store
+	mux            *router.Router
+	limit          config.RateLimit
+	routes         map[string]config.RateLimit
+	trustedProxies []netip.Prefix
+}
+
+// NewRateLimitConfig creates the limiter of the routes served by mux
+func NewRateLimitConfig(cfg *config.Config, store ratelimit.Store, mux *router.Router) *RateLimitConfig {
+	return &RateLimitConfig{
+		store:          store,
+		mux:            mux,
+		limit:          cfg.RateLimit,
+		routes:         cfg.RateLimitRoutes,
+		trustedProxies: cfg.TrustedProxies,
+	}
+}
+
+// RateLimit limits the requests of every client. It runs ahead of the router, so the route of a
+// request is looked up from the router: a route with a limit of its own keeps its own buckets, and
+// every other request shares the buckets of the default limit, including those that match no route.
+// Every client address has a bucket, and so does the user or bearer token of the request, so that
+// rotating those headers does not lift the limit of the address. A request is rejected when any of
+// its buckets is empty.
+func (c *RateLimitConfig) RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope, limit := c.limitFor(r)
+		if limit.Unlimited() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var result ratelimit.Result
+		for i, client := range c.clients(r) {
+			taken, err := c.store.Take(r.Context(), scope+" "+client, limit)
+			if err != nil {
+				// Failing open keeps the API up when the store is not
+				common.Logger(r.Context()).Error("failed to check rate limit", slog.Any("error", err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			if i == 0 || moreRestrictive(taken, result) {
+				result = taken
+			}
+		}
+
+		w.Header().Set(RateLimitPolicyHeader, strconv.Itoa(limit.Requests)+";w="+strconv.Itoa(ceilSeconds(limit.Period)))
+		w.Header().Set(RateLimitLimitHeader, strconv.Itoa(limit.Requests))
+		w.Header().Set(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+		w.Header().Set(RateLimitResetHeader, strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			common.HandleError(w, common.ErrRateLimited)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitFor returns the limit of the route that serves r and the scope of its buckets
+func (c *RateLimitConfig) limitFor(r *http.Request) (string, config.RateLimit) {
+	route, ok := c.mux.Match(r)
+	if !ok {
+		return "*", c.limit
+	}
+	for _, key := range []string{route.Method + " " + route.Pattern, route.Pattern} {
+		if l, ok := c.routes[key]; ok {
+			return key, l
+		}
+	}
+	return "*", c.limit
+}
+
+// moreRestrictive reports whether a leaves the client less room than b: a denial that lasts longer,
+// or fewer remaining requests
+func moreRestrictive(a, b ratelimit.Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
+
+// clients lists the buckets of the request: the client address, and then the user set by the Actor
+// middleware or else the bearer token, which is hashed so that it is not kept
+func (c *RateLimitConfig) clients(r *http.Request) []string {
+	clients := []string{"ip:" + c.clientIP(r)}
+	if actor := common.ActorFromContext(r.Context()); actor != common.AnonymousActor {
+		return append(clients, "user:"+actor)
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+		sum := sha256.Sum256([]byte(token))
+		return append(clients, "token:"+hex.EncodeToString(sum[:16]))
+	}
+	return clients
+}
+
+// clientIP returns the address the request came from. X-Forwarded-For is read from the right, as
+// only the entries added by trusted proxies can be believed, and the first untrusted address in it
+// is the client.
+func (c *RateLimitConfig) clientIP(r *http.Request) string {
+	ip, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	addr := ip.Addr().Unmap()
+	if !c.trusted(addr) {
+		return addr.String()
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !c.trusted(addr) {
+			break
+		}
+	}
+	return addr.String()
+}
+
+func (c *RateLimitConfig) trusted(addr netip.Addr) bool {
+	for _, prefix := range c.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"github.com/kenwoo9y/todo-api-go/api/internal/ratelimit"
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
+)
+
+func newRateLimitRouter(cfg *config.Config, store ratelimit.Store, routes ...router.Route) http.Handler {
+	for i := range routes {
+		routes[i].Handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+	mux := router.New(routes...)
+	return Chain{Actor, NewRateLimitConfig(cfg, store, mux).RateLimit}.Then(mux)
+}
+
+func TestRateLimit(t *testing.T) {
+	cfg := &config.Config{
+		RateLimit: config.RateLimit{Requests: 2, Period: time.Minute},
+		RateLimitRoutes: map[string]config.RateLimit{
+			"GET /tasks": {Requests: 1, Period: time.Second},
+			"/healthz":   {},
+		},
+	}
+	handler := newRateLimitRouter(cfg, ratelimit.NewMemoryStore(),
+		router.Route{Method: http.MethodGet, Pattern: "/tasks"},
+		router.Route{Method: http.MethodGet, Pattern: "/tasks/{id}"},
+		router.Route{Method: http.MethodGet, Pattern: "/users/{id}"},
+		router.Route{Method: http.MethodGet, Pattern: "/healthz"},
+	)
+
+	tests := []struct {
+		name              string
+		url               string
+		user              string
+		expectedStatus    int
+		expectedLimit     string
+		expectedRemaining string
+		expectedRetry     string
+	}{
+		{name: "Success: Route limit", url: "/tasks", user: "1", expectedStatus: http.StatusOK, expectedLimit: "1", expectedRemaining: "0"},
+		{name: "Error: Route limit exceeded", url: "/tasks", user: "1", expectedStatus: http.StatusTooManyRequests, expectedLimit: "1", expectedRemaining: "0", expectedRetry: "1"},
+		{name: "Success: Route limit of another user", url: "/tasks", user: "2", expectedStatus: http.StatusOK, expectedLimit: "1", expectedRemaining: "0"},
+		{name: "Success: Default limit", url: "/tasks/1", user: "1", expectedStatus: http.StatusOK, expectedLimit: "2", expectedRemaining: "1"},
+		{name: "Success: Default limit shared across routes", url: "/users/1", user: "1", expectedStatus: http.StatusOK, expectedLimit: "2", expectedRemaining: "0"},
+		{name: "Error: Default limit exceeded", url: "/tasks/2", user: "1", expectedStatus: http.StatusTooManyRequests, expectedLimit: "2", expectedRemaining: "0", expectedRetry: "30"},
+		{name: "Success: Unlimited route", url: "/healthz", user: "1", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			// Every user calls from an address of their own, which has a bucket as well
+			req.RemoteAddr = "192.0.2." + tt.user + ":1234"
+			req.Header.Set(ActorHeader, tt.user)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			for header, expected := range map[string]string{
+				RateLimitLimitHeader:     tt.expectedLimit,
+				RateLimitRemainingHeader: tt.expectedRemaining,
+				"Retry-After":            tt.expectedRetry,
+			} {
+				if got := w.Header().Get(header); got != expected {
+					t.Errorf("expected %s %q, got %q", header, expected, got)
+				}
+			}
+			if tt.expectedStatus == http.StatusTooManyRequests && w.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("expected a problem response, got %q", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestRateLimit_Client(t *testing.T) {
+	cfg := &config.Config{
+		RateLimit: config.RateLimit{Requests: 1, Period: time.Minute},
+		TrustedProxies: []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/8"),
+		},
+	}
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  string
+		authorization string
+		user          string
+		expectedKeys  []string
+	}{
+		{name: "Success: User", remoteAddr: "192.0.2.1:1234", authorization: "Bearer secret", user: "1", expectedKeys: []string{"* ip:192.0.2.1", "* user:1"}},
+		{name: "Success: Token", remoteAddr: "192.0.2.1:1234", authorization: "Bearer secret", expectedKeys: []string{"* ip:192.0.2.1", "* token:2bb80d537b1da3e38bd30361aa855686"}},
+		{name: "Success: Client address", remoteAddr: "192.0.2.1:1234", expectedKeys: []string{"* ip:192.0.2.1"}},
+		{name: "Success: Forwarded by untrusted address", remoteAddr: "192.0.2.1:1234", forwardedFor: "198.51.100.7", expectedKeys: []string{"* ip:192.0.2.1"}},
+		{name: "Success: Forwarded by trusted proxy", remoteAddr: "10.0.0.1:1234", forwardedFor: "198.51.100.7", expectedKeys: []string{"* ip:198.51.100.7"}},
+		{name: "Success: Spoofed entry before the client", remoteAddr: "10.0.0.1:1234", forwardedFor: "203.0.113.9, 198.51.100.7, 10.0.0.2", expectedKeys: []string{"* ip:198.51.100.7"}},
+		{name: "Success: Only trusted proxies", remoteAddr: "10.0.0.1:1234", forwardedFor: "10.0.0.3, 10.0.0.2", expectedKeys: []string{"* ip:10.0.0.3"}},
+		{name: "Success: Invalid forwarded entry", remoteAddr: "10.0.0.1:1234", forwardedFor: "unknown", expectedKeys: []string{"* ip:10.0.0.1"}},
+		{name: "Success: IPv6", remoteAddr: "[2001:db8::1]:1234", expectedKeys: []string{"* ip:2001:db8::1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &recordingStore{}
+			handler := newRateLimitRouter(cfg, store, router.Route{Method: http.MethodGet, Pattern: "/tasks"})
+
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.user != "" {
+				req.Header.Set(ActorHeader, tt.user)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !slices.Equal(store.keys, tt.expectedKeys) {
+				t.Errorf("expected keys %q, got %q", tt.expectedKeys, store.keys)
+			}
+		})
+	}
+}
+
+func TestRateLimit_RotatingHeaders(t *testing.T) {
+	cfg := &config.Config{RateLimit: config.RateLimit{Requests: 2, Period: time.Minute}}
+	handler := newRateLimitRouter(cfg, ratelimit.NewMemoryStore(), router.Route{Method: http.MethodGet, Pattern: "/tasks"})
+
+	tests := []struct {
+		name              string
+		user              string
+		authorization     string
+		expectedStatus    int
+		expectedRemaining string
+	}{
+		{name: "Success: First user", user: "1", expectedStatus: http.StatusOK, expectedRemaining: "1"},
+		{name: "Success: First token", authorization: "Bearer token-1", expectedStatus: http.StatusOK, expectedRemaining: "0"},
+		{name: "Error: Another user from the same address", user: "2", expectedStatus: http.StatusTooManyRequests, expectedRemaining: "0"},
+		{name: "Error: Another token from the same address", authorization: "Bearer token-2", expectedStatus: http.StatusTooManyRequests, expectedRemaining: "0"},
+		{name: "Error: No credentials", expectedStatus: http.StatusTooManyRequests, expectedRemaining: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			if tt.user != "" {
+				req.Header.Set(ActorHeader, tt.user)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get(RateLimitRemainingHeader); got != tt.expectedRemaining {
+				t.Errorf("expected %s %q, got %q", RateLimitRemainingHeader, tt.expectedRemaining, got)
+			}
+		})
+	}
+}
+
+func TestRateLimit_UnmatchedRequests(t *testing.T) {
+	cfg := &config.Config{RateLimit: config.RateLimit{Requests: 2, Period: time.Minute}}
+	handler := newRateLimitRouter(cfg, ratelimit.NewMemoryStore(), router.Route{Method: http.MethodGet, Pattern: "/tasks"})
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		expectedStatus int
+	}{
+		{name: "Error: Unknown path", method: http.MethodGet, url: "/unknown", expectedStatus: http.StatusNotFound},
+		{name: "Error: Method not allowed", method: http.MethodPost, url: "/tasks", expectedStatus: http.StatusMethodNotAllowed},
+		{name: "Error: Default limit spent by unmatched requests", method: http.MethodGet, url: "/unknown", expectedStatus: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Header().Get(RateLimitLimitHeader) != "2" {
+				t.Errorf("expected the default limit, got %q", w.Header().Get(RateLimitLimitHeader))
+			}
+		})
+	}
+}
+
+func TestRateLimit_StoreError(t *testing.T) {
+	cfg := &config.Config{RateLimit: config.RateLimit{Requests: 1, Period: time.Minute}}
+	store := &recordingStore{err: errors.New("ストアに接続できません")}
+	handler := newRateLimitRouter(cfg, store, router.Route{Method: http.MethodGet, Pattern: "/tasks"})
+
+	logs := captureLogs(t)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected the request to be let through, got status %d", w.Code)
+	}
+	if logs.Len() == 0 {
+		t.Error("expected the store error to be logged")
+	}
+}
+
+// recordingStore remembers the keys it was asked for and allows every request
+type recordingStore struct {
+	keys []string
+	err  error
+}
+
+func (s *recordingStore) Take(ctx context.Context, key string, limit config.RateLimit) (ratelimit.Result, error) {
+	s.keys = append(s.keys, key)
+	return ratelimit.Result{Allowed: true}, s.err
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+// Recover turns a panic in a handler into a 500 problem response and logs it with its stack, so
+// that the client gets an answer instead of a dropped connection. When the handler had already
+// started the response, only the log is written. http.ErrAbortHandler is passed on, since it is the
+// way to abort a response on purpose.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			common.Logger(r.Context()).LogAttrs(r.Context(), slog.LevelError, "panic serving request",
+				slog.Any("panic", v),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("stack", string(debug.Stack())),
+			)
+			if sw.status == 0 {
+				common.HandleError(sw, common.ErrInternalServer)
+			}
+		}()
+
+		next.ServeHTTP(sw, r)
+	})
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name           string
+		handler        http.HandlerFunc
+		expectedStatus int
+		expectedBody   string
+		expectedLogged bool
+	}{
+		{
+			name: "Panic before writing is answered with 500",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic("タスクの取得に失敗")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `"code":"internal_server_error"`,
+			expectedLogged: true,
+		},
+		{
+			name: "Panic after writing keeps the response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"id":1}`))
+				panic("タスクの取得に失敗")
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":1}`,
+			expectedLogged: true,
+		},
+		{
+			name: "No panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+			w := httptest.NewRecorder()
+
+			Recover(tt.handler).ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body to contain %q, got %q", tt.expectedBody, w.Body.String())
+			}
+			if w.Code == http.StatusInternalServerError && w.Header().Get("Content-Type") != common.ProblemContentType {
+				t.Errorf("expected problem content type, got %q", w.Header().Get("Content-Type"))
+			}
+			if logged := strings.Contains(logs.String(), "panic serving request"); logged != tt.expectedLogged {
+				t.Errorf("expected panic logged %v, got log %q", tt.expectedLogged, logs.String())
+			}
+		})
+	}
+}
+
+func TestRecover_AbortHandler(t *testing.T) {
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler to be passed on, got %v", v)
+		}
+	}()
+
+	handler := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tasks/1", nil))
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+const maxRequestIDLength = 128
+
+// RequestID assigns every request an ID, reusing the X-Request-ID sent by a proxy when it is
+// reasonable. The ID is echoed in the response header, stored in the request context and added to
+// the request logger.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(common.RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(common.RequestIDHeader, requestID)
+		ctx := common.WithRequestID(r.Context(), requestID)
+		ctx = common.WithLogger(ctx, common.Logger(ctx).With(slog.String("request_id", requestID)))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		// Printable ASCII only, so the ID is safe to log and echo
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
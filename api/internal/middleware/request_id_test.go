@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		reused   bool
+	}{
+		{name: "Generated when missing", incoming: "", reused: false},
+		{name: "Incoming ID is reused", incoming: "abc-123", reused: true},
+		{name: "Overlong ID is replaced", incoming: strings.Repeat("a", 129), reused: false},
+		{name: "ID with spaces is replaced", incoming: "abc 123", reused: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromContext string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromContext = common.RequestIDFromContext(r.Context())
+			})
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.incoming != "" {
+				req.Header.Set(common.RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+
+			RequestID(next).ServeHTTP(w, req)
+
+			got := w.Header().Get(common.RequestIDHeader)
+			if got == "" || got != fromContext {
+				t.Errorf("expected the same ID in the header and context, got %q and %q", got, fromContext)
+			}
+			if (got == tt.incoming) != tt.reused {
+				t.Errorf("expected reused %v, got ID %q", tt.reused, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		tls          bool
+		expectedHSTS string
+	}{
+		{name: "Success: Plain HTTP", tls: false, expectedHSTS: ""},
+		{name: "Success: TLS", tls: true, expectedHSTS: "max-age=63072000; includeSubDomains"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := SecurityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			for name, expected := range map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+				"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+				"Referrer-Policy":           "no-referrer",
+				"Strict-Transport-Security": tt.expectedHSTS,
+			} {
+				if got := w.Header().Get(name); got != expected {
+					t.Errorf("expected %s %q, got %q", name, expected, got)
+				}
+			}
+		})
+	}
+}
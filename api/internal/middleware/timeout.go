@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+)
+
+type TimeoutConfig struct {
+	timeout time.Duration
+}
+
+func NewTimeoutConfig(cfg *config.Config) *TimeoutConfig {
+	return &TimeoutConfig{timeout: cfg.RequestTimeout}
+}
+
+// Timeout sets a deadline on the request context. Repositories run their queries with that context,
+// so the database stops working on a request once it has run out of time, and the handler answers
+// 503 with the error it gets back.
+func (c *TimeoutConfig) Timeout(next http.Handler) http.Handler {
+	if c.timeout <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), c.timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name             string
+		timeout          time.Duration
+		expectedDeadline bool
+		expectedStatus   int
+	}{
+		{name: "Error: Deadline exceeded", timeout: 10 * time.Millisecond, expectedDeadline: true, expectedStatus: http.StatusServiceUnavailable},
+		{name: "Success: Timeout disabled", timeout: 0, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, ok := r.Context().Deadline(); !ok {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				// Stands in for a query that runs until the deadline cancels it
+				<-r.Context().Done()
+				common.HandleError(w, r.Context().Err())
+			})
+			handler := NewTimeoutConfig(&config.Config{RequestTimeout: tt.timeout}).Timeout(next)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/tracing"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type TracingConfig struct {
+	provider *sdktrace.TracerProvider
+}
+
+func NewTracingConfig(provider *sdktrace.TracerProvider) *TracingConfig {
+	return &TracingConfig{provider: provider}
+}
+
+// Tracing starts a server span for every request with otelhttp, continuing the trace named by the
+// traceparent header when the caller sent one. The span is named after the route pattern, such as
+// GET /tasks/{id}, and the trace ID is added to the request logger. Without a tracer provider
+// requests are passed on untouched.
+func (c *TracingConfig) Tracing(next http.Handler) http.Handler {
+	if c.provider == nil {
+		return next
+	}
+
+	named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := common.WithRoute(r.Context())
+		span := trace.SpanFromContext(ctx)
+		ctx = common.WithLogger(ctx, common.Logger(ctx).With(slog.String("trace_id", span.SpanContext().TraceID().String())))
+
+		defer func() {
+			if route := common.RouteFromContext(ctx); route != "" {
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(attribute.String("http.route", route))
+			}
+		}()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+
+	return otelhttp.NewHandler(named, "",
+		otelhttp.WithTracerProvider(c.provider),
+		otelhttp.WithPropagators(tracing.Propagator),
+		// Until the router has matched a route, only the method is known
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string { return r.Method }),
+	)
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	rt := router.New(
+		router.Route{Method: http.MethodGet, Pattern: "/tasks/{id}", Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}},
+		router.Route{Method: http.MethodDelete, Pattern: "/tasks/{id}", Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}},
+	)
+	handler := Chain{NewTracingConfig(provider).Tracing, AccessLog}.Then(rt)
+
+	logs := captureLogs(t)
+	req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/tasks/1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	got := recorder.Ended()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(got))
+	}
+	if got[0].Name() != "GET /tasks/{id}" || got[0].SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || got[0].Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("expected the span to continue the caller's trace, got %s in %s", got[0].Name(), got[0].SpanContext().TraceID())
+	}
+	if !hasAttribute(got[0], attribute.String("http.route", "/tasks/{id}")) || !hasAttribute(got[0], attribute.Int("http.status_code", http.StatusOK)) {
+		t.Errorf("expected the route and status in the attributes, got %v", got[0].Attributes())
+	}
+	if got[1].Name() != "DELETE /tasks/{id}" || got[1].Parent().IsValid() || got[1].Status().Code != codes.Error {
+		t.Errorf("expected a failed root span, got %s with status %v", got[1].Name(), got[1].Status())
+	}
+	if got[2].Name() != "GET" {
+		t.Errorf("expected an unmatched request to be named after its method, got %q", got[2].Name())
+	}
+	if !strings.Contains(logs.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`) {
+		t.Errorf("expected the trace ID in the access log, got %s", logs.String())
+	}
+}
+
+func TestTracing_Disabled(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if trace.SpanFromContext(r.Context()).SpanContext().IsValid() {
+			t.Error("expected no span without a tracer")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	NewTracingConfig(nil).Tracing(next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func hasAttribute(span sdktrace.ReadOnlySpan, want attribute.KeyValue) bool {
+	for _, attr := range span.Attributes() {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+// Package ratelimit keeps the token buckets that limit how often clients may call the API.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+)
+
+// Result describes the bucket of a client after a request took, or failed to take, a token from it
+type Result struct {
+	Allowed bool
+	// Remaining is the number of requests the client can still make right away
+	Remaining int
+	// RetryAfter is the time until the next token, set only when the request was not allowed
+	RetryAfter time.Duration
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps a token bucket per key. Implementations shared by several servers let them enforce
+// one limit together.
+type Store interface {
+	// Take takes a token from the bucket of key, holding limit.Requests tokens refilled over
+	// limit.Period, and reports whether there was one
+	Take(ctx context.Context, key string, limit config.RateLimit) (Result, error)
+}
+
+// sweepInterval is how often MemoryStore drops buckets that have refilled, as they hold nothing a
+// new bucket would not
+const sweepInterval = time.Minute
+
+// MemoryStore keeps the buckets in the memory of the process
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit config.RateLimit) (Result, error) {
+	if limit.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	capacity := float64(limit.Requests)
+	perToken := limit.Period / time.Duration(limit.Requests)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.updated))/float64(perToken))
+	b.updated = now
+
+	result := Result{}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((capacity - b.tokens) * float64(perToken))
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(sweepInterval)
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
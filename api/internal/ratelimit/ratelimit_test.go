@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	limit := config.RateLimit{Requests: 3, Period: 3 * time.Second}
+
+	tests := []struct {
+		name              string
+		after             time.Duration
+		expectedAllowed   bool
+		expectedRemaining int
+		expectedRetry     time.Duration
+		expectedReset     time.Duration
+	}{
+		{name: "Success: First request", expectedAllowed: true, expectedRemaining: 2, expectedReset: time.Second},
+		{name: "Success: Second request", expectedAllowed: true, expectedRemaining: 1, expectedReset: 2 * time.Second},
+		{name: "Success: Third request", expectedAllowed: true, expectedRemaining: 0, expectedReset: 3 * time.Second},
+		{name: "Error: Bucket empty", expectedAllowed: false, expectedRemaining: 0, expectedRetry: time.Second, expectedReset: 3 * time.Second},
+		{name: "Error: Bucket half refilled", after: 500 * time.Millisecond, expectedAllowed: false, expectedRemaining: 0, expectedRetry: 500 * time.Millisecond, expectedReset: 2500 * time.Millisecond},
+		{name: "Success: Token refilled", after: 500 * time.Millisecond, expectedAllowed: true, expectedRemaining: 0, expectedReset: 3 * time.Second},
+		{name: "Success: Bucket full again", after: time.Hour, expectedAllowed: true, expectedRemaining: 2, expectedReset: time.Second},
+	}
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.after)
+			result, err := store.Take(context.Background(), "user:1", limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := Result{Allowed: tt.expectedAllowed, Remaining: tt.expectedRemaining, RetryAfter: tt.expectedRetry, Reset: tt.expectedReset}
+			if result != expected {
+				t.Errorf("expected %+v, got %+v", expected, result)
+			}
+		})
+	}
+}
+
+func TestMemoryStore_SeparateKeys(t *testing.T) {
+	store := NewMemoryStore()
+	limit := config.RateLimit{Requests: 1, Period: time.Minute}
+
+	for _, key := range []string{"* user:1", "* user:2", "GET /tasks user:1"} {
+		if result, _ := store.Take(context.Background(), key, limit); !result.Allowed {
+			t.Errorf("expected the first request of %s to be allowed", key)
+		}
+	}
+	if result, _ := store.Take(context.Background(), "* user:1", limit); result.Allowed {
+		t.Error("expected the second request of user 1 to be limited")
+	}
+}
+
+func TestMemoryStore_Unlimited(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 10; i++ {
+		if result, _ := store.Take(context.Background(), "ip:192.0.2.1", config.RateLimit{}); !result.Allowed {
+			t.Fatal("expected an unlimited request to be allowed")
+		}
+	}
+	if len(store.buckets) != 0 {
+		t.Errorf("expected no bucket for an unlimited key, got %d", len(store.buckets))
+	}
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	store.Take(context.Background(), "ip:192.0.2.1", config.RateLimit{Requests: 10, Period: time.Second})
+	store.Take(context.Background(), "ip:192.0.2.2", config.RateLimit{Requests: 10, Period: time.Hour})
+
+	now = now.Add(2 * sweepInterval)
+	store.Take(context.Background(), "ip:192.0.2.3", config.RateLimit{Requests: 10, Period: time.Hour})
+
+	if _, ok := store.buckets["ip:192.0.2.1"]; ok {
+		t.Error("expected the refilled bucket to be dropped")
+	}
+	if _, ok := store.buckets["ip:192.0.2.2"]; !ok {
+		t.Error("expected the bucket still refilling to be kept")
+	}
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+	"github.com/lib/pq"
+)
+
+type ConstraintKind string
+
+const (
+	ConstraintUnique     ConstraintKind = "unique"
+	ConstraintForeignKey ConstraintKind = "foreign_key"
+	ConstraintNotNull    ConstraintKind = "not_null"
+	ConstraintTooLong    ConstraintKind = "too_long"
+)
+
+// ConstraintError is a write rejected by a database constraint. It matches the common error of its
+// kind, so handlers report it with the right status, and keeps the driver error for logging.
+type ConstraintError struct {
+	Kind  ConstraintKind
+	Field string
+	Err   error
+}
+
+func (e *ConstraintError) Error() string {
+	field := e.Field
+	if field == "" {
+		field = "value"
+	}
+	switch e.Kind {
+	case ConstraintUnique:
+		return fmt.Sprintf("%s already exists", field)
+	case ConstraintForeignKey:
+		return fmt.Sprintf("%s refers to a record that does not exist", field)
+	case ConstraintNotNull:
+		return fmt.Sprintf("%s is required", field)
+	default:
+		return fmt.Sprintf("%s is too long", field)
+	}
+}
+
+func (e *ConstraintError) Unwrap() []error {
+	return []error{e.kindError(), e.Err}
+}
+
+func (e *ConstraintError) kindError() error {
+	switch e.Kind {
+	case ConstraintUnique:
+		return common.ErrAlreadyExists
+	case ConstraintForeignKey:
+		return common.ErrInvalidReference
+	case ConstraintNotNull:
+		return common.ErrRequiredField
+	default:
+		return common.ErrValueTooLong
+	}
+}
+
+// FieldErrors names the rejected field in the error response
+func (e *ConstraintError) FieldErrors() []common.FieldError {
+	if e.Field == "" {
+		return nil
+	}
+	return []common.FieldError{{Field: e.Field, Code: string(e.Kind), Message: e.Error()}}
+}
+
+// constraintFields maps the names the databases give to the unnamed constraints in _tools/*/schema.sql
+// to the request fields they check. MySQL names a unique key after its column and numbers foreign
+// keys, so its foreign keys are read from the error message instead.
+var constraintFields = map[string]string{
+	"username":              "username",
+	"email":                 "email",
+	"users_username_key":    "username",
+	"users_email_key":       "email",
+	"tasks_owner_id_fkey":   "owner_id",
+	"tasks_project_id_fkey": "project_id",
+}
+
+var (
+	mysqlDuplicateKeyPattern = regexp.MustCompile(`for key '(?:[^']*\.)?([^']+)'`)
+	mysqlForeignKeyPattern   = regexp.MustCompile("FOREIGN KEY \\(`([^`]+)`\\)")
+	mysqlColumnPattern       = regexp.MustCompile(`olumn '([^']+)'`)
+	postgresKeyPattern       = regexp.MustCompile(`Key \(([^)]+)\)=`)
+	postgresColumnPattern    = regexp.MustCompile(`column "?([^"\s:]+)"?`)
+)
+
+// translateError converts constraint violations reported by either driver into a *ConstraintError.
+// Other errors are returned unchanged.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062:
+			return &ConstraintError{Kind: ConstraintUnique, Field: constraintField(submatch(mysqlDuplicateKeyPattern, mysqlErr.Message)), Err: err}
+		case 1216, 1452:
+			return &ConstraintError{Kind: ConstraintForeignKey, Field: submatch(mysqlForeignKeyPattern, mysqlErr.Message), Err: err}
+		case 1048, 1364:
+			return &ConstraintError{Kind: ConstraintNotNull, Field: submatch(mysqlColumnPattern, mysqlErr.Message), Err: err}
+		case 1406:
+			return &ConstraintError{Kind: ConstraintTooLong, Field: submatch(mysqlColumnPattern, mysqlErr.Message), Err: err}
+		}
+		return err
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return &ConstraintError{Kind: ConstraintUnique, Field: postgresField(pqErr), Err: err}
+		case "23503":
+			return &ConstraintError{Kind: ConstraintForeignKey, Field: postgresField(pqErr), Err: err}
+		case "23502":
+			return &ConstraintError{Kind: ConstraintNotNull, Field: postgresColumn(pqErr), Err: err}
+		case "22001":
+			return &ConstraintError{Kind: ConstraintTooLong, Field: postgresColumn(pqErr), Err: err}
+		}
+	}
+	return err
+}
+
+func postgresField(pqErr *pq.Error) string {
+	if field, ok := constraintFields[pqErr.Constraint]; ok {
+		return field
+	}
+	if columns := submatch(postgresKeyPattern, pqErr.Detail); !strings.Contains(columns, ",") {
+		return columns
+	}
+	return ""
+}
+
+// postgresColumn returns the column of an error about a single value. Errors that leave the Column
+// field empty, such as a value that is too long, may still name it in their message or context.
+func postgresColumn(pqErr *pq.Error) string {
+	if pqErr.Column != "" {
+		return pqErr.Column
+	}
+	for _, s := range []string{pqErr.Message, pqErr.Detail, pqErr.Where} {
+		if column := submatch(postgresColumnPattern, s); column != "" {
+			return column
+		}
+	}
+	return ""
+}
+
+func constraintField(constraint string) string {
+	if field, ok := constraintFields[constraint]; ok {
+		return field
+	}
+	return ""
+}
+
+func submatch(pattern *regexp.Regexp, s string) string {
+	if m := pattern.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// requireRowsAffected returns common.ErrNotFound when a write matched no rows
+func requireRowsAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return common.ErrNotFound
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a duplicate key error from either database
+func isUniqueViolation(err error) bool {
+	var constraintErr *ConstraintError
+	return errors.As(translateError(err), &constraintErr) && constraintErr.Kind == ConstraintUnique
+}
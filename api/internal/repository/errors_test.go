@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+	"github.com/lib/pq"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		expectedKind  ConstraintKind
+		expectedField string
+		expectedErr   error
+	}{
+		{
+			name:          "MySQL duplicate username",
+			err:           &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'taro' for key 'users.username'"},
+			expectedKind:  ConstraintUnique,
+			expectedField: "username",
+			expectedErr:   common.ErrAlreadyExists,
+		},
+		{
+			name:          "MySQL nonexistent owner",
+			err:           &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`todo`.`tasks`, CONSTRAINT `tasks_ibfk_1` FOREIGN KEY (`owner_id`) REFERENCES `users` (`id`))"},
+			expectedKind:  ConstraintForeignKey,
+			expectedField: "owner_id",
+			expectedErr:   common.ErrInvalidReference,
+		},
+		{
+			name:          "MySQL nonexistent project",
+			err:           &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails (`todo`.`tasks`, CONSTRAINT `tasks_ibfk_2` FOREIGN KEY (`project_id`) REFERENCES `projects` (`id`))"},
+			expectedKind:  ConstraintForeignKey,
+			expectedField: "project_id",
+			expectedErr:   common.ErrInvalidReference,
+		},
+		{
+			name:          "MySQL null column",
+			err:           &mysql.MySQLError{Number: 1048, Message: "Column 'title' cannot be null"},
+			expectedKind:  ConstraintNotNull,
+			expectedField: "title",
+			expectedErr:   common.ErrRequiredField,
+		},
+		{
+			name:          "MySQL value too long",
+			err:           &mysql.MySQLError{Number: 1406, Message: "Data too long for column 'title' at row 1"},
+			expectedKind:  ConstraintTooLong,
+			expectedField: "title",
+			expectedErr:   common.ErrValueTooLong,
+		},
+		{
+			name:          "PostgreSQL duplicate email",
+			err:           &pq.Error{Code: "23505", Constraint: "users_email_key", Detail: "Key (email)=(taro@example.com) already exists."},
+			expectedKind:  ConstraintUnique,
+			expectedField: "email",
+			expectedErr:   common.ErrAlreadyExists,
+		},
+		{
+			name:          "PostgreSQL nonexistent project",
+			err:           &pq.Error{Code: "23503", Detail: `Key (project_id)=(99) is not present in table "projects".`},
+			expectedKind:  ConstraintForeignKey,
+			expectedField: "project_id",
+			expectedErr:   common.ErrInvalidReference,
+		},
+		{
+			name:          "PostgreSQL null column",
+			err:           &pq.Error{Code: "23502", Column: "title"},
+			expectedKind:  ConstraintNotNull,
+			expectedField: "title",
+			expectedErr:   common.ErrRequiredField,
+		},
+		{
+			name:          "PostgreSQL value too long in a column",
+			err:           &pq.Error{Code: "22001", Message: "value too long for type character varying(30)", Column: "title"},
+			expectedKind:  ConstraintTooLong,
+			expectedField: "title",
+			expectedErr:   common.ErrValueTooLong,
+		},
+		{
+			name:          "PostgreSQL value too long named in the context",
+			err:           &pq.Error{Code: "22001", Message: "value too long for type character varying(30)", Where: "COPY tasks, line 1, column title: \"締め切りが近いとても長いタイトルのタスク\""},
+			expectedKind:  ConstraintTooLong,
+			expectedField: "title",
+			expectedErr:   common.ErrValueTooLong,
+		},
+		{
+			name:         "PostgreSQL value too long without a column",
+			err:          &pq.Error{Code: "22001", Message: "value too long for type character varying(30)"},
+			expectedKind: ConstraintTooLong,
+			expectedErr:  common.ErrValueTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := translateError(tt.err)
+
+			var constraintErr *ConstraintError
+			if !errors.As(err, &constraintErr) {
+				t.Fatalf("expected a constraint error, got %v", err)
+			}
+			if constraintErr.Kind != tt.expectedKind || constraintErr.Field != tt.expectedField {
+				t.Errorf("expected %s on %q, got %s on %q", tt.expectedKind, tt.expectedField, constraintErr.Kind, constraintErr.Field)
+			}
+			if !errors.Is(err, tt.expectedErr) || !errors.Is(err, tt.err) {
+				t.Errorf("expected the error to match %v and the driver error", tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestTranslateError_Unchanged(t *testing.T) {
+	for _, err := range []error{
+		nil,
+		errors.New("connection refused"),
+		&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"},
+		&pq.Error{Code: "40001"},
+	} {
+		if got := translateError(err); got != err {
+			t.Errorf("expected %v to be returned unchanged, got %v", err, got)
+		}
+	}
+}
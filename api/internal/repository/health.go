@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// HealthRepository answers the readiness checks of the database
+type HealthRepository interface {
+	// Ping checks that a connection to the database can be established
+	Ping(ctx context.Context) error
+	// CheckSchema checks that every table and column the repositories query exists, which is the
+	// case once the schema in _tools has been applied
+	CheckSchema(ctx context.Context) error
+}
+
+// schema lists the columns the repositories rely on by table. It follows _tools/*/schema.sql and
+// has to be extended together with it.
+var schema = []struct {
+	table   string
+	columns []string
+}{
+	{"users", []string{"id", "username", "email", "first_name", "last_name", "created_at", "updated_at", "version", "deleted_at"}},
+	{"projects", []string{"id", "name", "description", "color", "archived", "created_at", "updated_at"}},
+	{"tasks", []string{"id", "title", "description", "due_date", "status", "owner_id", "project_id", "archived", "created_at", "updated_at", "version", "deleted_at", "deleted_by_cascade", "completed_at"}},
+	{"task_history", []string{"id", "task_id", "action", "field", "old_value", "new_value", "actor", "created_at"}},
+	{"idempotency_keys", []string{"actor", "idempotency_key", "fingerprint", "status_code", "content_type", "etag", "response_body", "created_at", "expires_at"}},
+}
+
+type healthRepository struct {
+	db *sql.DB
+}
+
+func NewHealthRepository(db *sql.DB) HealthRepository {
+	return &healthRepository{db: db}
+}
+
+func (r *healthRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *healthRepository) CheckSchema(ctx context.Context) error {
+	for _, t := range schema {
+		// Selecting no rows fails on a missing table or column without touching any data
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE 1 = 0", strings.Join(t.columns, ", "), t.table)
+		rows, err := r.db.QueryContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("table %s is missing or out of date: %w", t.table, err)
+		}
+		rows.Close()
+	}
+	return nil
+}
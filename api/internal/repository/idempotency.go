@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+type IdempotencyRepository interface {
+	// Reserve stores a new in-progress record. When an unexpired record already exists for the
+	// actor and key, nothing is stored and the existing record is returned instead.
+	Reserve(ctx context.Context, record *entity.IdempotencyRecord) (*entity.IdempotencyRecord, error)
+	// Complete stores the response of a reserved request
+	Complete(ctx context.Context, record *entity.IdempotencyRecord) error
+	// Release removes a reservation so that the request can be retried
+	Release(ctx context.Context, actor, key string) error
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db     *sql.DB
+	dbType string
+}
+
+func NewIdempotencyRepository(db *sql.DB, cfg *config.Config) IdempotencyRepository {
+	return &idempotencyRepository{
+		db:     db,
+		dbType: cfg.DBType,
+	}
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, record *entity.IdempotencyRecord) (*entity.IdempotencyRecord, error) {
+	var deleteQuery, insertQuery string
+	if r.dbType == "mysql" {
+		deleteQuery = `DELETE FROM idempotency_keys WHERE actor = ? AND idempotency_key = ? AND expires_at < ?`
+		insertQuery = `
+			INSERT INTO idempotency_keys (actor, idempotency_key, fingerprint, created_at, expires_at)
+			VALUES (?, ?, ?, ?, ?)`
+	} else {
+		deleteQuery = `DELETE FROM idempotency_keys WHERE actor = $1 AND idempotency_key = $2 AND expires_at < $3`
+		insertQuery = `
+			INSERT INTO idempotency_keys (actor, idempotency_key, fingerprint, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5)`
+	}
+
+	// An expired record no longer protects the key
+	if _, err := r.db.ExecContext(ctx, deleteQuery, record.Actor, record.Key, record.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		insertQuery,
+		record.Actor,
+		record.Key,
+		record.Fingerprint,
+		record.CreatedAt,
+		record.ExpiresAt,
+	)
+	if err == nil {
+		return nil, nil
+	}
+	if !isUniqueViolation(err) {
+		return nil, err
+	}
+
+	existing, err := r.get(ctx, record.Actor, record.Key)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		// The other request released its reservation in the meantime
+		return r.Reserve(ctx, record)
+	}
+	return existing, nil
+}
+
+func (r *idempotencyRepository) get(ctx context.Context, actor, key string) (*entity.IdempotencyRecord, error) {
+	var query string
+	if r.dbType == "mysql" {
+		query = `
+			SELECT actor, idempotency_key, fingerprint, status_code, content_type, etag, response_body, created_at, expires_at
+			FROM idempotency_keys WHERE actor = ? AND idempotency_key = ?`
+	} else {
+		query = `
+			SELECT actor, idempotency_key, fingerprint, status_code, content_type, etag, response_body, created_at, expires_at
+			FROM idempotency_keys WHERE actor = $1 AND idempotency_key = $2`
+	}
+
+	var record entity.IdempotencyRecord
+	err := r.db.QueryRowContext(ctx, query, actor, key).Scan(
+		&record.Actor,
+		&record.Key,
+		&record.Fingerprint,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.ETag,
+		&record.Body,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &record, err
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, record *entity.IdempotencyRecord) error {
+	var query string
+	if r.dbType == "mysql" {
+		query = `UPDATE idempotency_keys SET status_code = ?, content_type = ?, etag = ?, response_body = ? WHERE actor = ? AND idempotency_key = ?`
+	} else {
+		query = `UPDATE idempotency_keys SET status_code = $1, content_type = $2, etag = $3, response_body = $4 WHERE actor = $5 AND idempotency_key = $6`
+	}
+	_, err := r.db.ExecContext(ctx, query, record.StatusCode, record.ContentType, record.ETag, record.Body, record.Actor, record.Key)
+	return err
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, actor, key string) error {
+	var query string
+	if r.dbType == "mysql" {
+		query = `DELETE FROM idempotency_keys WHERE actor = ? AND idempotency_key = ?`
+	} else {
+		query = `DELETE FROM idempotency_keys WHERE actor = $1 AND idempotency_key = $2`
+	}
+	_, err := r.db.ExecContext(ctx, query, actor, key)
+	return err
+}
+
+// PurgeExpired removes the records that expired before the given time
+func (r *idempotencyRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	var query string
+	if r.dbType == "mysql" {
+		query = `DELETE FROM idempotency_keys WHERE expires_at < ?`
+	} else {
+		query = `DELETE FROM idempotency_keys WHERE expires_at < $1`
+	}
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+// Observer is told about every call made through an instrumented repository. Observe is called
+// before the call with the name of the repository and of the method; the call runs with the
+// returned context, and the returned function is called with its error once it returns.
+type Observer interface {
+	Observe(ctx context.Context, repository, method string) (context.Context, func(err error))
+}
+
+// Observers reports every call to each of its observers in turn. The first observer is the
+// outermost: it sees the call start first and end last.
+type Observers []Observer
+
+func (o Observers) Observe(ctx context.Context, repository, method string) (context.Context, func(err error)) {
+	dones := make([]func(err error), len(o))
+	for i, observer := range o {
+		ctx, dones[i] = observer.Observe(ctx, repository, method)
+	}
+	return ctx, func(err error) {
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](err)
+		}
+	}
+}
+
+// InstrumentTaskRepository reports every call made through repo to observer
+func InstrumentTaskRepository(repo TaskRepository, observer Observer) TaskRepository {
+	return &instrumentedTaskRepository{next: repo, observer: observer}
+}
+
+type instrumentedTaskRepository struct {
+	next     TaskRepository
+	observer Observer
+}
+
+func (r *instrumentedTaskRepository) Create(ctx context.Context, task *entity.Task) (err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, task)
+}
+
+func (r *instrumentedTaskRepository) GetAll(ctx context.Context, opts TaskListOptions) (tasks []entity.Task, err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "GetAll")
+	defer func() { done(err) }()
+	return r.next.GetAll(ctx, opts)
+}
+
+func (r *instrumentedTaskRepository) GetByID(ctx context.Context, id int64) (task *entity.Task, err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "GetByID")
+	defer func() { done(err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedTaskRepository) GetByOwnerID(ctx context.Context, ownerID int64, opts TaskListOptions) (tasks []entity.Task, err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "GetByOwnerID")
+	defer func() { done(err) }()
+	return r.next.GetByOwnerID(ctx, ownerID, opts)
+}
+
+func (r *instrumentedTaskRepository) GetByProjectID(ctx context.Context, projectID int64, opts TaskListOptions) (tasks []entity.Task, err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "GetByProjectID")
+	defer func() { done(err) }()
+	return r.next.GetByProjectID(ctx, projectID, opts)
+}
+
+func (r *instrumentedTaskRepository) Stream(ctx context.Context, opts TaskListOptions, fn func(task *entity.Task) error) (err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "Stream")
+	defer func() { done(err) }()
+	return r.next.Stream(ctx, opts, fn)
+}
+
+func (r *instrumentedTaskRepository) Update(ctx context.Context, task *entity.Task) (err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "Update")
+	defer func() { done(err) }()
+	return r.next.Update(ctx, task)
+}
+
+func (r *instrumentedTaskRepository) SetArchived(ctx context.Context, id int64, archived bool) (task *entity.Task, err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "SetArchived")
+	defer func() { done(err) }()
+	return r.next.SetArchived(ctx, id, archived)
+}
+
+func (r *instrumentedTaskRepository) ArchiveDoneBefore(ctx context.Context, before time.Time) (n int64, err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "ArchiveDoneBefore")
+	defer func() { done(err) }()
+	return r.next.ArchiveDoneBefore(ctx, before)
+}
+
+func (r *instrumentedTaskRepository) MoveToProject(ctx context.Context, projectID int64, taskIDs []int64) (err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "MoveToProject")
+	defer func() { done(err) }()
+	return r.next.MoveToProject(ctx, projectID, taskIDs)
+}
+
+func (r *instrumentedTaskRepository) Delete(ctx context.Context, id int64, version int64) (err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, id, version)
+}
+
+func (r *instrumentedTaskRepository) GetDeleted(ctx context.Context) (tasks []entity.Task, err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "GetDeleted")
+	defer func() { done(err) }()
+	return r.next.GetDeleted(ctx)
+}
+
+func (r *instrumentedTaskRepository) Restore(ctx context.Context, id int64) (task *entity.Task, err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "Restore")
+	defer func() { done(err) }()
+	return r.next.Restore(ctx, id)
+}
+
+func (r *instrumentedTaskRepository) PurgeDeleted(ctx context.Context, before time.Time) (n int64, err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "PurgeDeleted")
+	defer func() { done(err) }()
+	return r.next.PurgeDeleted(ctx, before)
+}
+
+func (r *instrumentedTaskRepository) CountByStatus(ctx context.Context) (counts map[entity.TaskStatus]int64, err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "CountByStatus")
+	defer func() { done(err) }()
+	return r.next.CountByStatus(ctx)
+}
+
+// WithTx reports the transaction as a whole and every call made through the bound repository
+func (r *instrumentedTaskRepository) WithTx(ctx context.Context, fn func(repo TaskRepository) error) (err error) {
+	ctx, done := r.observer.Observe(ctx, "task", "WithTx")
+	defer func() { done(err) }()
+	return r.next.WithTx(ctx, func(repo TaskRepository) error {
+		return fn(&instrumentedTaskRepository{next: repo, observer: r.observer})
+	})
+}
+
+// InstrumentUserRepository reports every call made through repo to observer
+func InstrumentUserRepository(repo UserRepository, observer Observer) UserRepository {
+	return &instrumentedUserRepository{next: repo, observer: observer}
+}
+
+type instrumentedUserRepository struct {
+	next     UserRepository
+	observer Observer
+}
+
+func (r *instrumentedUserRepository) Create(ctx context.Context, user *entity.User) (err error) {
+	ctx, done := r.observer.Observe(ctx, "user", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, user)
+}
+
+func (r *instrumentedUserRepository) GetAll(ctx context.Context) (users []entity.User, err error) {
+	ctx, done := r.observer.Observe(ctx, "user", "GetAll")
+	defer func() { done(err) }()
+	return r.next.GetAll(ctx)
+}
+
+func (r *instrumentedUserRepository) GetByID(ctx context.Context, id int64) (user *entity.User, err error) {
+	ctx, done := r.observer.Observe(ctx, "user", "GetByID")
+	defer func() { done(err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedUserRepository) GetByUsername(ctx context.Context, username string) (user *entity.User, err error) {
+	ctx, done := r.observer.Observe(ctx, "user", "GetByUsername")
+	defer func() { done(err) }()
+	return r.next.GetByUsername(ctx, username)
+}
+
+func (r *instrumentedUserRepository) Update(ctx context.Context, user *entity.User) (err error) {
+	ctx, done := r.observer.Observe(ctx, "user", "Update")
+	defer func() { done(err) }()
+	return r.next.Update(ctx, user)
+}
+
+func (r *instrumentedUserRepository) Delete(ctx context.Context, id int64, version int64) (err error) {
+	ctx, done := r.observer.Observe(ctx, "user", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, id, version)
+}
+
+func (r *instrumentedUserRepository) GetDeleted(ctx context.Context) (users []entity.User, err error) {
+	ctx, done := r.observer.Observe(ctx, "user", "GetDeleted")
+	defer func() { done(err) }()
+	return r.next.GetDeleted(ctx)
+}
+
+func (r *instrumentedUserRepository) Restore(ctx context.Context, id int64) (user *entity.User, err error) {
+	ctx, done := r.observer.Observe(ctx, "user", "Restore")
+	defer func() { done(err) }()
+	return r.next.Restore(ctx, id)
+}
+
+func (r *instrumentedUserRepository) PurgeDeleted(ctx context.Context, before time.Time) (n int64, err error) {
+	ctx, done := r.observer.Observe(ctx, "user", "PurgeDeleted")
+	defer func() { done(err) }()
+	return r.next.PurgeDeleted(ctx, before)
+}
+
+// InstrumentProjectRepository reports every call made through repo to observer
+func InstrumentProjectRepository(repo ProjectRepository, observer Observer) ProjectRepository {
+	return &instrumentedProjectRepository{next: repo, observer: observer}
+}
+
+type instrumentedProjectRepository struct {
+	next     ProjectRepository
+	observer Observer
+}
+
+func (r *instrumentedProjectRepository) Create(ctx context.Context, project *entity.Project) (err error) {
+	ctx, done := r.observer.Observe(ctx, "project", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, project)
+}
+
+func (r *instrumentedProjectRepository) GetAll(ctx context.Context) (projects []entity.Project, err error) {
+	ctx, done := r.observer.Observe(ctx, "project", "GetAll")
+	defer func() { done(err) }()
+	return r.next.GetAll(ctx)
+}
+
+func (r *instrumentedProjectRepository) GetByID(ctx context.Context, id int64) (project *entity.Project, err error) {
+	ctx, done := r.observer.Observe(ctx, "project", "GetByID")
+	defer func() { done(err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedProjectRepository) Update(ctx context.Context, project *entity.Project) (err error) {
+	ctx, done := r.observer.Observe(ctx, "project", "Update")
+	defer func() { done(err) }()
+	return r.next.Update(ctx, project)
+}
+
+func (r *instrumentedProjectRepository) Delete(ctx context.Context, id int64, mode ProjectDeleteMode, targetID int64) (err error) {
+	ctx, done := r.observer.Observe(ctx, "project", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, id, mode, targetID)
+}
+
+// InstrumentTaskHistoryRepository reports every call made through repo to observer
+func InstrumentTaskHistoryRepository(repo TaskHistoryRepository, observer Observer) TaskHistoryRepository {
+	return &instrumentedTaskHistoryRepository{next: repo, observer: observer}
+}
+
+type instrumentedTaskHistoryRepository struct {
+	next     TaskHistoryRepository
+	observer Observer
+}
+
+func (r *instrumentedTaskHistoryRepository) GetByTaskID(ctx context.Context, taskID int64, limit, offset int) (history []entity.TaskHistory, total int64, err error) {
+	ctx, done := r.observer.Observe(ctx, "task_history", "GetByTaskID")
+	defer func() { done(err) }()
+	return r.next.GetByTaskID(ctx, taskID, limit, offset)
+}
+
+// InstrumentIdempotencyRepository reports every call made through repo to observer
+func InstrumentIdempotencyRepository(repo IdempotencyRepository, observer Observer) IdempotencyRepository {
+	return &instrumentedIdempotencyRepository{next: repo, observer: observer}
+}
+
+type instrumentedIdempotencyRepository struct {
+	next     IdempotencyRepository
+	observer Observer
+}
+
+func (r *instrumentedIdempotencyRepository) Reserve(ctx context.Context, record *entity.IdempotencyRecord) (existing *entity.IdempotencyRecord, err error) {
+	ctx, done := r.observer.Observe(ctx, "idempotency", "Reserve")
+	defer func() { done(err) }()
+	return r.next.Reserve(ctx, record)
+}
+
+func (r *instrumentedIdempotencyRepository) Complete(ctx context.Context, record *entity.IdempotencyRecord) (err error) {
+	ctx, done := r.observer.Observe(ctx, "idempotency", "Complete")
+	defer func() { done(err) }()
+	return r.next.Complete(ctx, record)
+}
+
+func (r *instrumentedIdempotencyRepository) Release(ctx context.Context, actor, key string) (err error) {
+	ctx, done := r.observer.Observe(ctx, "idempotency", "Release")
+	defer func() { done(err) }()
+	return r.next.Release(ctx, actor, key)
+}
+
+func (r *instrumentedIdempotencyRepository) PurgeExpired(ctx context.Context, before time.Time) (n int64, err error) {
+	ctx, done := r.observer.Observe(ctx, "idempotency", "PurgeExpired")
+	defer func() { done(err) }()
+	return r.next.PurgeExpired(ctx, before)
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+// ProjectDeleteMode controls what happens to a project and its tasks on deletion
+type ProjectDeleteMode string
+
+const (
+	// ProjectDeleteArchive keeps the project and its tasks but marks the project as archived
+	ProjectDeleteArchive ProjectDeleteMode = "archive"
+	// ProjectDeleteCascade removes the project together with all of its tasks
+	ProjectDeleteCascade ProjectDeleteMode = "cascade"
+	// ProjectDeleteReassign moves the tasks to another project before removing the project
+	ProjectDeleteReassign ProjectDeleteMode = "reassign"
+)
+
+type ProjectRepository interface {
+	Create(ctx context.Context, project *entity.Project) error
+	GetAll(ctx context.Context) ([]entity.Project, error)
+	GetByID(ctx context.Context, id int64) (*entity.Project, error)
+	Update(ctx context.Context, project *entity.Project) error
+	// Delete removes the project according to mode. targetID is only used by ProjectDeleteReassign.
+	Delete(ctx context.Context, id int64, mode ProjectDeleteMode, targetID int64) error
+}
+
+type projectRepository struct {
+	db     *sql.DB
+	dbType string
+}
+
+func NewProjectRepository(db *sql.DB, cfg *config.Config) ProjectRepository {
+	return &projectRepository{
+		db:     db,
+		dbType: cfg.DBType,
+	}
+}
+
+func (r *projectRepository) Create(ctx context.Context, project *entity.Project) error {
+	var query string
+	if r.dbType == "mysql" {
+		query = `
+			INSERT INTO projects (name, description, color, archived, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)`
+	} else {
+		query = `
+			INSERT INTO projects (name, description, color, archived, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id`
+	}
+
+	now := time.Now()
+	if r.dbType == "mysql" {
+		result, err := r.db.ExecContext(ctx,
+			query,
+			project.Name,
+			project.Description,
+			project.Color,
+			project.Archived,
+			now,
+			now,
+		)
+		if err != nil {
+			return translateError(err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		project.ID = id
+		return nil
+	} else {
+		return translateError(r.db.QueryRowContext(ctx,
+			query,
+			project.Name,
+			project.Description,
+			project.Color,
+			project.Archived,
+			now,
+			now,
+		).Scan(&project.ID))
+	}
+}
+
+func (r *projectRepository) GetAll(ctx context.Context) ([]entity.Project, error) {
+	query := `SELECT id, name, description, color, archived, created_at, updated_at FROM projects ORDER BY archived ASC, name ASC`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []entity.Project
+	for rows.Next() {
+		var project entity.Project
+		if err := rows.Scan(
+			&project.ID,
+			&project.Name,
+			&project.Description,
+			&project.Color,
+			&project.Archived,
+			&project.CreatedAt,
+			&project.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+func (r *projectRepository) GetByID(ctx context.Context, id int64) (*entity.Project, error) {
+	var project entity.Project
+	var query string
+	if r.dbType == "mysql" {
+		query = `SELECT id, name, description, color, archived, created_at, updated_at FROM projects WHERE id = ?`
+	} else {
+		query = `SELECT id, name, description, color, archived, created_at, updated_at FROM projects WHERE id = $1`
+	}
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&project.ID,
+		&project.Name,
+		&project.Description,
+		&project.Color,
+		&project.Archived,
+		&project.CreatedAt,
+		&project.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &project, err
+}
+
+func (r *projectRepository) Update(ctx context.Context, project *entity.Project) error {
+	var query string
+	if r.dbType == "mysql" {
+		query = `
+			UPDATE projects
+			SET name = ?, description = ?, color = ?, archived = ?, updated_at = ?
+			WHERE id = ?`
+	} else {
+		query = `
+			UPDATE projects
+			SET name = $1, description = $2, color = $3, archived = $4, updated_at = $5
+			WHERE id = $6`
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		query,
+		project.Name,
+		project.Description,
+		project.Color,
+		project.Archived,
+		time.Now(),
+		project.ID,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+	return requireRowsAffected(result)
+}
+
+func (r *projectRepository) Delete(ctx context.Context, id int64, mode ProjectDeleteMode, targetID int64) error {
+	if mode == ProjectDeleteArchive {
+		var query string
+		if r.dbType == "mysql" {
+			query = `UPDATE projects SET archived = ?, updated_at = ? WHERE id = ?`
+		} else {
+			query = `UPDATE projects SET archived = $1, updated_at = $2 WHERE id = $3`
+		}
+		result, err := r.db.ExecContext(ctx, query, true, time.Now(), id)
+		if err != nil {
+			return err
+		}
+		return requireRowsAffected(result)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the affected tasks so their history can be recorded in the same transaction
+	var selectQuery string
+	if r.dbType == "mysql" {
+		selectQuery = taskSelectQuery(r.dbType) + ` WHERE project_id = ? FOR UPDATE`
+	} else {
+		selectQuery = taskSelectQuery(r.dbType) + ` WHERE project_id = $1 FOR UPDATE`
+	}
+	rows, err := tx.QueryContext(ctx, selectQuery, id)
+	if err != nil {
+		return err
+	}
+	tasks, err := scanTasks(rows)
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	var tasksQuery string
+	var tasksArgs []interface{}
+	switch mode {
+	case ProjectDeleteCascade:
+		// Tasks go to the trash detached from the project so the project row can be removed
+		if r.dbType == "mysql" {
+			tasksQuery = `UPDATE tasks SET project_id = NULL, deleted_at = COALESCE(deleted_at, ?), updated_at = ?, version = version + 1 WHERE project_id = ?`
+		} else {
+			tasksQuery = `UPDATE tasks SET project_id = NULL, deleted_at = COALESCE(deleted_at, $1), updated_at = $2, version = version + 1 WHERE project_id = $3`
+		}
+		now := time.Now()
+		tasksArgs = []interface{}{now, now, id}
+	case ProjectDeleteReassign:
+		if r.dbType == "mysql" {
+			tasksQuery = `UPDATE tasks SET project_id = ?, updated_at = ?, version = version + 1 WHERE project_id = ?`
+		} else {
+			tasksQuery = `UPDATE tasks SET project_id = $1, updated_at = $2, version = version + 1 WHERE project_id = $3`
+		}
+		tasksArgs = []interface{}{targetID, time.Now(), id}
+	default:
+		return fmt.Errorf("unsupported project delete mode: %s", mode)
+	}
+	if _, err := tx.ExecContext(ctx, tasksQuery, tasksArgs...); err != nil {
+		return translateError(err)
+	}
+
+	for i := range tasks {
+		before := &tasks[i]
+		if mode == ProjectDeleteCascade {
+			if before.DeletedAt != nil {
+				continue
+			}
+			err = recordTaskHistory(ctx, tx, r.dbType, entity.TaskHistoryActionDelete, before.ID, before, nil)
+		} else {
+			after := *before
+			after.ProjectID = &targetID
+			err = recordTaskHistory(ctx, tx, r.dbType, entity.TaskHistoryActionUpdate, before.ID, before, &after)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	var query string
+	if r.dbType == "mysql" {
+		query = `DELETE FROM projects WHERE id = ?`
+	} else {
+		query = `DELETE FROM projects WHERE id = $1`
+	}
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if err := requireRowsAffected(result); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
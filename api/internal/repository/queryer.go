@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// queryer is implemented by both *sql.DB and *sql.Tx so queries can run inside or outside a transaction
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
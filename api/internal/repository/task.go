@@ -3,24 +3,98 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/kenwoo9y/todo-api-go/api/internal/config"
 	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
 )
 
 type TaskRepository interface {
 	Create(ctx context.Context, task *entity.Task) error
-	GetAll(ctx context.Context) ([]entity.Task, error)
+	GetAll(ctx context.Context, opts TaskListOptions) ([]entity.Task, error)
 	GetByID(ctx context.Context, id int64) (*entity.Task, error)
-	GetByOwnerID(ctx context.Context, ownerID int64) ([]entity.Task, error)
+	GetByOwnerID(ctx context.Context, ownerID int64, opts TaskListOptions) ([]entity.Task, error)
+	GetByProjectID(ctx context.Context, projectID int64, opts TaskListOptions) ([]entity.Task, error)
+	Stream(ctx context.Context, opts TaskListOptions, fn func(task *entity.Task) error) error
 	Update(ctx context.Context, task *entity.Task) error
-	Delete(ctx context.Context, id int64) error
+	// SetArchived archives or unarchives a task. It returns nil when the task does not exist.
+	SetArchived(ctx context.Context, id int64, archived bool) (*entity.Task, error)
+	// ArchiveDoneBefore archives every task that has been Done since before the given time
+	ArchiveDoneBefore(ctx context.Context, before time.Time) (int64, error)
+	MoveToProject(ctx context.Context, projectID int64, taskIDs []int64) error
+	// Delete moves the task to the trash
+	Delete(ctx context.Context, id int64, version int64) error
+	GetDeleted(ctx context.Context) ([]entity.Task, error)
+	Restore(ctx context.Context, id int64) (*entity.Task, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	// CountByStatus counts the tasks outside the trash by status
+	CountByStatus(ctx context.Context) (map[entity.TaskStatus]int64, error)
+	// WithTx runs fn with a repository bound to a single transaction that is committed when fn returns nil.
+	// Each mutation made through that repository runs in its own savepoint, so a failed operation is
+	// rolled back on its own and the transaction stays usable.
+	WithTx(ctx context.Context, fn func(repo TaskRepository) error) error
 }
 
+// TaskListOptions filters task listings. Zero values do not filter.
+type TaskListOptions struct {
+	IncludeArchived bool
+	Status          entity.TaskStatus
+	OwnerID         *int64
+	ProjectID       *int64
+}
+
+// conditions returns the WHERE conditions that apply the options
+func (o TaskListOptions) conditions() string {
+	conditions := `deleted_at IS NULL`
+	if !o.IncludeArchived {
+		conditions += ` AND archived = FALSE`
+	}
+	return conditions
+}
+
+// where returns the WHERE clause that applies every option together with its arguments
+func (o TaskListOptions) where(dbType string) (string, []interface{}) {
+	clause := ` WHERE ` + o.conditions()
+	var args []interface{}
+	add := func(column string, value interface{}) {
+		args = append(args, value)
+		if dbType == "mysql" {
+			clause += ` AND ` + column + ` = ?`
+		} else {
+			clause += ` AND ` + column + ` = $` + strconv.Itoa(len(args))
+		}
+	}
+
+	if o.Status != "" {
+		add("status", o.Status)
+	}
+	if o.OwnerID != nil {
+		add("owner_id", *o.OwnerID)
+	}
+	if o.ProjectID != nil {
+		add("project_id", *o.ProjectID)
+	}
+	return clause, args
+}
+
+// Ordering shared by every task listing: unfinished tasks first, then by due date, newest first
+const taskOrderBy = `
+	ORDER BY
+		CASE status
+			WHEN 'Done' THEN 1
+			ELSE 0
+		END ASC,
+		due_date ASC,
+		created_at DESC`
+
 type taskRepository struct {
 	db     *sql.DB
+	tx     *sql.Tx // set on repositories bound to a transaction by WithTx
+	seq    *int    // savepoint counter shared by all repositories bound to tx
 	dbType string
 }
 
@@ -31,33 +105,88 @@ func NewTaskRepository(db *sql.DB, cfg *config.Config) TaskRepository {
 	}
 }
 
+func (r *taskRepository) WithTx(ctx context.Context, fn func(repo TaskRepository) error) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	seq := r.seq
+	if seq == nil {
+		seq = new(int)
+	}
+	if err := fn(&taskRepository{db: r.db, tx: tx.Tx, seq: seq, dbType: r.dbType}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// begin starts a transaction, or a savepoint when the repository is bound to one
+func (r *taskRepository) begin(ctx context.Context) (*txScope, error) {
+	return beginScope(ctx, r.db, r.tx, r.seq)
+}
+
+// conn returns the transaction the repository is bound to, or the database otherwise
+func (r *taskRepository) conn() queryer {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// taskSelectQuery returns the SELECT clause for tasks with due_date formatted as YYYY-MM-DD
+func taskSelectQuery(dbType string) string {
+	if dbType == "mysql" {
+		return `SELECT id, title, description, DATE_FORMAT(due_date, '%Y-%m-%d') as due_date, status, owner_id, project_id, archived, created_at, updated_at, version, deleted_at FROM tasks`
+	}
+	return `SELECT id, title, description, TO_CHAR(due_date, 'YYYY-MM-DD') as due_date, status, owner_id, project_id, archived, created_at, updated_at, version, deleted_at FROM tasks`
+}
+
 func (r *taskRepository) Create(ctx context.Context, task *entity.Task) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	var query string
 	if r.dbType == "mysql" {
 		query = `
-			INSERT INTO tasks (title, description, due_date, status, owner_id, created_at, updated_at)
-			VALUES (?, ?, STR_TO_DATE(?, '%Y-%m-%d'), ?, ?, ?, ?)`
+			INSERT INTO tasks (title, description, due_date, status, owner_id, project_id, completed_at, created_at, updated_at)
+			VALUES (?, ?, STR_TO_DATE(?, '%Y-%m-%d'), ?, ?, ?, ?, ?, ?)`
 	} else {
 		query = `
-			INSERT INTO tasks (title, description, due_date, status, owner_id, created_at, updated_at)
-			VALUES ($1, $2, $3::date, $4, $5, $6, $7)
+			INSERT INTO tasks (title, description, due_date, status, owner_id, project_id, completed_at, created_at, updated_at)
+			VALUES ($1, $2, $3::date, $4, $5, $6, $7, $8, $9)
 			RETURNING id`
 	}
 
+	// The foreign key alone would accept an owner in the trash
+	if err := checkLiveUser(ctx, tx, r.dbType, task.OwnerID); err != nil {
+		return err
+	}
+
 	now := time.Now()
+	var completedAt *time.Time
+	if task.Status == entity.TaskStatusDone {
+		completedAt = &now
+	}
 	if r.dbType == "mysql" {
-		result, err := r.db.ExecContext(ctx,
+		result, err := tx.ExecContext(ctx,
 			query,
 			task.Title,
 			task.Description,
 			task.DueDate,
 			task.Status,
 			task.OwnerID,
+			task.ProjectID,
+			completedAt,
 			now,
 			now,
 		)
 		if err != nil {
-			return err
+			return translateError(err)
 		}
 
 		id, err := result.LastInsertId()
@@ -65,181 +194,481 @@ func (r *taskRepository) Create(ctx context.Context, task *entity.Task) error {
 			return err
 		}
 		task.ID = id
-		return nil
 	} else {
-		return r.db.QueryRowContext(ctx,
+		if err := tx.QueryRowContext(ctx,
 			query,
 			task.Title,
 			task.Description,
 			task.DueDate,
 			task.Status,
 			task.OwnerID,
+			task.ProjectID,
+			completedAt,
 			now,
 			now,
-		).Scan(&task.ID)
+		).Scan(&task.ID); err != nil {
+			return translateError(err)
+		}
+	}
+
+	task.Version = 1
+	if err := recordTaskHistory(ctx, tx, r.dbType, entity.TaskHistoryActionCreate, task.ID, nil, task); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *taskRepository) GetAll(ctx context.Context, opts TaskListOptions) ([]entity.Task, error) {
+	var tasks []entity.Task
+	err := r.Stream(ctx, opts, func(task *entity.Task) error {
+		tasks = append(tasks, *task)
+		return nil
+	})
+	return tasks, err
+}
+
+func (r *taskRepository) GetByID(ctx context.Context, id int64) (*entity.Task, error) {
+	return getTask(ctx, r.conn(), r.dbType, id, false, false)
+}
+
+func (r *taskRepository) GetByOwnerID(ctx context.Context, ownerID int64, opts TaskListOptions) ([]entity.Task, error) {
+	opts.OwnerID = &ownerID
+	return r.GetAll(ctx, opts)
+}
+
+func (r *taskRepository) GetByProjectID(ctx context.Context, projectID int64, opts TaskListOptions) ([]entity.Task, error) {
+	opts.ProjectID = &projectID
+	return r.GetAll(ctx, opts)
+}
+
+// Stream calls fn for each matching task in listing order without loading them all into memory.
+// It stops at the first error returned by fn.
+func (r *taskRepository) Stream(ctx context.Context, opts TaskListOptions, fn func(task *entity.Task) error) error {
+	where, args := opts.where(r.dbType)
+	query := taskSelectQuery(r.dbType) + where + taskOrderBy
+	rows, err := r.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var task entity.Task
+		if err := scanTask(rows, &task); err != nil {
+			return err
+		}
+		if err := fn(&task); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *taskRepository) Update(ctx context.Context, task *entity.Task) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getTask(ctx, tx, r.dbType, task.ID, true, false)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return common.ErrNotFound
+	}
+	if task.OwnerID != before.OwnerID {
+		if err := checkLiveUser(ctx, tx, r.dbType, task.OwnerID); err != nil {
+			return err
+		}
+	}
+
+	var query string
+	if r.dbType == "mysql" {
+		query = `
+			UPDATE tasks
+			SET title = ?, description = ?, due_date = STR_TO_DATE(?, '%Y-%m-%d'), status = ?, owner_id = ?, project_id = ?,
+				completed_at = CASE WHEN ? THEN completed_at ELSE ? END, updated_at = ?, version = version + 1
+			WHERE id = ? AND version = ?`
+	} else {
+		query = `
+			UPDATE tasks
+			SET title = $1, description = $2, due_date = $3::date, status = $4, owner_id = $5, project_id = $6,
+				completed_at = CASE WHEN $7 THEN completed_at ELSE $8 END, updated_at = $9, version = version + 1
+			WHERE id = $10 AND version = $11`
+	}
+
+	// completed_at is kept while the task stays Done so that later edits do not reset auto-archiving
+	now := time.Now()
+	stillDone := before.Status == entity.TaskStatusDone && task.Status == entity.TaskStatusDone
+	var completedAt *time.Time
+	if task.Status == entity.TaskStatusDone {
+		completedAt = &now
 	}
+	result, err := tx.ExecContext(ctx,
+		query,
+		task.Title,
+		task.Description,
+		task.DueDate,
+		task.Status,
+		task.OwnerID,
+		task.ProjectID,
+		stillDone,
+		completedAt,
+		now,
+		task.ID,
+		task.Version,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return common.ErrPreconditionFailed
+	}
+	task.Version++
+	task.UpdatedAt = now
+
+	if err := recordTaskHistory(ctx, tx, r.dbType, entity.TaskHistoryActionUpdate, task.ID, before, task); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *taskRepository) GetAll(ctx context.Context) ([]entity.Task, error) {
+// MoveToProject assigns all of the given tasks to the project in a single transaction. Nothing is
+// moved when one of the tasks does not exist or is in the trash, and ErrNotFound names that task.
+func (r *taskRepository) MoveToProject(ctx context.Context, projectID int64, taskIDs []int64) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	var query string
 	if r.dbType == "mysql" {
-		query = `SELECT id, title, description, DATE_FORMAT(due_date, '%Y-%m-%d') as due_date, status, owner_id, created_at, updated_at FROM tasks
-			ORDER BY
-				CASE status
-					WHEN 'Done' THEN 1
-					ELSE 0
-				END ASC,
-				due_date ASC,
-				created_at DESC`
+		query = `UPDATE tasks SET project_id = ?, updated_at = ?, version = version + 1 WHERE id = ?`
 	} else {
-		query = `SELECT id, title, description, TO_CHAR(due_date, 'YYYY-MM-DD') as due_date, status, owner_id, created_at, updated_at FROM tasks
-			ORDER BY
-				CASE status
-					WHEN 'Done' THEN 1
-					ELSE 0
-				END ASC,
-				due_date ASC,
-				created_at DESC`
+		query = `UPDATE tasks SET project_id = $1, updated_at = $2, version = version + 1 WHERE id = $3`
+	}
+
+	now := time.Now()
+	for _, id := range taskIDs {
+		before, err := getTask(ctx, tx, r.dbType, id, true, false)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return fmt.Errorf("%w: task %d", common.ErrNotFound, id)
+		}
+
+		if _, err := tx.ExecContext(ctx, query, projectID, now, id); err != nil {
+			return translateError(err)
+		}
+
+		after := *before
+		after.ProjectID = &projectID
+		if err := recordTaskHistory(ctx, tx, r.dbType, entity.TaskHistoryActionUpdate, id, before, &after); err != nil {
+			return err
+		}
 	}
-	rows, err := r.db.QueryContext(ctx, query)
+
+	return tx.Commit()
+}
+
+func (r *taskRepository) SetArchived(ctx context.Context, id int64, archived bool) (*entity.Task, error) {
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	var tasks []entity.Task
-	for rows.Next() {
-		var task entity.Task
-		if err := rows.Scan(
-			&task.ID,
-			&task.Title,
-			&task.Description,
-			&task.DueDate,
-			&task.Status,
-			&task.OwnerID,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		); err != nil {
-			return nil, err
+	before, err := getTask(ctx, tx, r.dbType, id, true, false)
+	if err != nil || before == nil {
+		return nil, err
+	}
+
+	task, err := r.setArchived(ctx, tx, before, archived)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (r *taskRepository) ArchiveDoneBefore(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var query string
+	if r.dbType == "mysql" {
+		query = taskSelectQuery(r.dbType) + ` WHERE status = ? AND COALESCE(completed_at, updated_at) < ? AND ` + TaskListOptions{}.conditions() + ` FOR UPDATE`
+	} else {
+		query = taskSelectQuery(r.dbType) + ` WHERE status = $1 AND COALESCE(completed_at, updated_at) < $2 AND ` + TaskListOptions{}.conditions() + ` FOR UPDATE`
+	}
+	rows, err := tx.QueryContext(ctx, query, entity.TaskStatusDone, before)
+	if err != nil {
+		return 0, err
+	}
+	tasks, err := scanTasks(rows)
+	rows.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range tasks {
+		if _, err := r.setArchived(ctx, tx, &tasks[i], true); err != nil {
+			return 0, err
 		}
-		tasks = append(tasks, task)
 	}
-	return tasks, rows.Err()
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int64(len(tasks)), nil
 }
 
-func (r *taskRepository) GetByID(ctx context.Context, id int64) (*entity.Task, error) {
-	var task entity.Task
+// setArchived updates the archived flag of a locked task and records the change
+func (r *taskRepository) setArchived(ctx context.Context, tx queryer, before *entity.Task, archived bool) (*entity.Task, error) {
+	after := *before
+	if before.Archived == archived {
+		return &after, nil
+	}
+
 	var query string
 	if r.dbType == "mysql" {
-		query = `SELECT id, title, description, DATE_FORMAT(due_date, '%Y-%m-%d') as due_date, status, owner_id, created_at, updated_at FROM tasks WHERE id = ?`
+		query = `UPDATE tasks SET archived = ?, updated_at = ?, version = version + 1 WHERE id = ?`
 	} else {
-		query = `SELECT id, title, description, TO_CHAR(due_date, 'YYYY-MM-DD') as due_date, status, owner_id, created_at, updated_at FROM tasks WHERE id = $1`
+		query = `UPDATE tasks SET archived = $1, updated_at = $2, version = version + 1 WHERE id = $3`
+	}
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, query, archived, now, before.ID); err != nil {
+		return nil, err
 	}
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&task.ID,
-		&task.Title,
-		&task.Description,
-		&task.DueDate,
-		&task.Status,
-		&task.OwnerID,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
-	if err == sql.ErrNoRows {
-		return nil, nil
+	after.Archived = archived
+	after.UpdatedAt = now
+	after.Version++
+	if err := recordTaskHistory(ctx, tx, r.dbType, entity.TaskHistoryActionUpdate, before.ID, before, &after); err != nil {
+		return nil, err
 	}
-	return &task, err
+	return &after, nil
 }
 
-func (r *taskRepository) GetByOwnerID(ctx context.Context, ownerID int64) ([]entity.Task, error) {
-	baseQuery := `
-		SELECT id, title, description, DATE_FORMAT(due_date, '%%Y-%%m-%%d') as due_date, status, owner_id, created_at, updated_at FROM tasks 
-		WHERE owner_id = %s
-		ORDER BY
-			CASE status
-				WHEN 'Done' THEN 1
-				ELSE 0
-			END ASC,
-			due_date ASC,
-			created_at DESC`
+// Delete moves a task to the trash. A non-zero version makes the deletion conditional on the task
+// still being at that version; otherwise ErrPreconditionFailed is returned. ErrNotFound is returned
+// when there is no such task outside the trash.
+func (r *taskRepository) Delete(ctx context.Context, id int64, version int64) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getTask(ctx, tx, r.dbType, id, true, false)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return common.ErrNotFound
+	}
 
 	var query string
+	now := time.Now()
+	args := []interface{}{now, now, id}
 	if r.dbType == "mysql" {
-		query = fmt.Sprintf(baseQuery, "?")
+		query = `UPDATE tasks SET deleted_at = ?, updated_at = ?, version = version + 1 WHERE id = ?`
+		if version != 0 {
+			query += ` AND version = ?`
+			args = append(args, version)
+		}
 	} else {
-		query = fmt.Sprintf(`
-			SELECT id, title, description, TO_CHAR(due_date, 'YYYY-MM-DD') as due_date, status, owner_id, created_at, updated_at FROM tasks 
-			WHERE owner_id = $1
-			ORDER BY
-				CASE status
-					WHEN 'Done' THEN 1
-					ELSE 0
-				END ASC,
-				due_date ASC,
-				created_at DESC`)
+		query = `UPDATE tasks SET deleted_at = $1, updated_at = $2, version = version + 1 WHERE id = $3`
+		if version != 0 {
+			query += ` AND version = $4`
+			args = append(args, version)
+		}
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return common.ErrPreconditionFailed
+	}
+
+	if err := recordTaskHistory(ctx, tx, r.dbType, entity.TaskHistoryActionDelete, id, before, nil); err != nil {
+		return err
 	}
+	return tx.Commit()
+}
 
-	rows, err := r.db.QueryContext(ctx, query, ownerID)
+func (r *taskRepository) GetDeleted(ctx context.Context) ([]entity.Task, error) {
+	query := taskSelectQuery(r.dbType) + ` WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+	rows, err := r.conn().QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var tasks []entity.Task
-	for rows.Next() {
-		var task entity.Task
-		if err := rows.Scan(
-			&task.ID,
-			&task.Title,
-			&task.Description,
-			&task.DueDate,
-			&task.Status,
-			&task.OwnerID,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		); err != nil {
-			return nil, err
+	return scanTasks(rows)
+}
+
+// Restore takes a task out of the trash. It returns nil when no trashed task has the given id, and
+// ErrOwnerInTrash while the owner of the task is in the trash, as the task would be left with an
+// owner that cannot be purged.
+func (r *taskRepository) Restore(ctx context.Context, id int64) (*entity.Task, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	task, err := getTask(ctx, tx, r.dbType, id, true, true)
+	if err != nil || task == nil {
+		return nil, err
+	}
+	if err := checkLiveUser(ctx, tx, r.dbType, task.OwnerID); err != nil {
+		var constraintErr *ConstraintError
+		if errors.As(err, &constraintErr) {
+			return nil, common.ErrOwnerInTrash
 		}
-		tasks = append(tasks, task)
+		return nil, err
 	}
-	return tasks, rows.Err()
-}
 
-func (r *taskRepository) Update(ctx context.Context, task *entity.Task) error {
 	var query string
 	if r.dbType == "mysql" {
-		query = `
-			UPDATE tasks
-			SET title = ?, description = ?, due_date = STR_TO_DATE(?, '%Y-%m-%d'), status = ?, owner_id = ?, updated_at = ?
-			WHERE id = ?`
+		query = `UPDATE tasks SET deleted_at = NULL, deleted_by_cascade = FALSE, updated_at = ?, version = version + 1 WHERE id = ?`
 	} else {
-		query = `
-			UPDATE tasks
-			SET title = $1, description = $2, due_date = $3::date, status = $4, owner_id = $5, updated_at = $6
-			WHERE id = $7`
+		query = `UPDATE tasks SET deleted_at = NULL, deleted_by_cascade = FALSE, updated_at = $1, version = version + 1 WHERE id = $2`
+	}
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, query, now, id); err != nil {
+		return nil, err
 	}
 
-	_, err := r.db.ExecContext(ctx,
-		query,
-		task.Title,
-		task.Description,
-		task.DueDate,
-		task.Status,
-		task.OwnerID,
-		time.Now(),
-		task.ID,
-	)
-	return err
+	task.DeletedAt = nil
+	task.UpdatedAt = now
+	task.Version++
+	if err := recordTaskHistory(ctx, tx, r.dbType, entity.TaskHistoryActionRestore, id, nil, task); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return task, nil
 }
 
-func (r *taskRepository) Delete(ctx context.Context, id int64) error {
+// PurgeDeleted permanently removes tasks that were moved to the trash before the given time
+func (r *taskRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
 	var query string
 	if r.dbType == "mysql" {
-		query = `DELETE FROM tasks WHERE id = ?`
+		query = `DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < ?`
 	} else {
-		query = `DELETE FROM tasks WHERE id = $1`
+		query = `DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+	}
+	result, err := r.conn().ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
 	}
-	_, err := r.db.ExecContext(ctx, query, id)
-	return err
+	return result.RowsAffected()
+}
+
+func (r *taskRepository) CountByStatus(ctx context.Context) (map[entity.TaskStatus]int64, error) {
+	rows, err := r.conn().QueryContext(ctx, `SELECT status, COUNT(*) FROM tasks WHERE deleted_at IS NULL GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[entity.TaskStatus]int64)
+	for rows.Next() {
+		var status entity.TaskStatus
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+// getTask loads a single task, locking the row when forUpdate is set. It returns nil when the task does not exist
+// or, unless deleted is set, when it is in the trash. With deleted set only trashed tasks are returned.
+func getTask(ctx context.Context, q queryer, dbType string, id int64, forUpdate bool, deleted bool) (*entity.Task, error) {
+	var task entity.Task
+	var query string
+	if dbType == "mysql" {
+		query = taskSelectQuery(dbType) + ` WHERE id = ?`
+	} else {
+		query = taskSelectQuery(dbType) + ` WHERE id = $1`
+	}
+	if deleted {
+		query += ` AND deleted_at IS NOT NULL`
+	} else {
+		query += ` AND deleted_at IS NULL`
+	}
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+
+	err := scanTask(q.QueryRowContext(ctx, query, id), &task)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return &task, err
+}
+
+// queryTasks runs a query built on taskSelectQuery and returns every task it selects
+func queryTasks(ctx context.Context, q queryer, query string, args ...interface{}) ([]entity.Task, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTasks(rows)
+}
+
+func scanTasks(rows *sql.Rows) ([]entity.Task, error) {
+	var tasks []entity.Task
+	for rows.Next() {
+		var task entity.Task
+		if err := scanTask(rows, &task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTask(row rowScanner, task *entity.Task) error {
+	return row.Scan(
+		&task.ID,
+		&task.Title,
+		&task.Description,
+		&task.DueDate,
+		&task.Status,
+		&task.OwnerID,
+		&task.ProjectID,
+		&task.Archived,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+		&task.Version,
+		&task.DeletedAt,
+	)
 }
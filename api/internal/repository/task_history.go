@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+type TaskHistoryRepository interface {
+	// GetByTaskID returns a page of changes for the task, newest first, together with the total count.
+	// A task in the trash keeps its history, while an unknown or purged task is ErrNotFound.
+	GetByTaskID(ctx context.Context, taskID int64, limit, offset int) ([]entity.TaskHistory, int64, error)
+}
+
+type taskHistoryRepository struct {
+	db     *sql.DB
+	dbType string
+}
+
+func NewTaskHistoryRepository(db *sql.DB, cfg *config.Config) TaskHistoryRepository {
+	return &taskHistoryRepository{
+		db:     db,
+		dbType: cfg.DBType,
+	}
+}
+
+func (r *taskHistoryRepository) GetByTaskID(ctx context.Context, taskID int64, limit, offset int) ([]entity.TaskHistory, int64, error) {
+	var existsQuery, countQuery, query string
+	if r.dbType == "mysql" {
+		existsQuery = `SELECT 1 FROM tasks WHERE id = ?`
+		countQuery = `SELECT COUNT(*) FROM task_history WHERE task_id = ?`
+		query = `
+			SELECT id, task_id, action, field, old_value, new_value, actor, created_at FROM task_history
+			WHERE task_id = ?
+			ORDER BY id DESC
+			LIMIT ? OFFSET ?`
+	} else {
+		existsQuery = `SELECT 1 FROM tasks WHERE id = $1`
+		countQuery = `SELECT COUNT(*) FROM task_history WHERE task_id = $1`
+		query = `
+			SELECT id, task_id, action, field, old_value, new_value, actor, created_at FROM task_history
+			WHERE task_id = $1
+			ORDER BY id DESC
+			LIMIT $2 OFFSET $3`
+	}
+
+	var exists int
+	if err := r.db.QueryRowContext(ctx, existsQuery, taskID).Scan(&exists); err == sql.ErrNoRows {
+		return nil, 0, common.ErrNotFound
+	} else if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, countQuery, taskID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, taskID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	history := []entity.TaskHistory{}
+	for rows.Next() {
+		var h entity.TaskHistory
+		if err := rows.Scan(
+			&h.ID,
+			&h.TaskID,
+			&h.Action,
+			&h.Field,
+			&h.OldValue,
+			&h.NewValue,
+			&h.Actor,
+			&h.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		history = append(history, h)
+	}
+	return history, total, rows.Err()
+}
+
+type taskField struct {
+	name  string
+	value *string
+}
+
+// taskHistoryFields lists the audited fields of a task in a fixed order
+func taskHistoryFields(task *entity.Task) []taskField {
+	str := func(s string) *string { return &s }
+
+	var projectID *string
+	if task.ProjectID != nil {
+		projectID = str(strconv.FormatInt(*task.ProjectID, 10))
+	}
+
+	return []taskField{
+		{name: "title", value: str(task.Title)},
+		{name: "description", value: str(task.Description)},
+		{name: "due_date", value: str(task.DueDate)},
+		{name: "status", value: str(string(task.Status))},
+		{name: "owner_id", value: str(strconv.FormatInt(task.OwnerID, 10))},
+		{name: "project_id", value: projectID},
+		{name: "archived", value: str(strconv.FormatBool(task.Archived))},
+	}
+}
+
+// recordTaskHistory appends one history row per changed field. before is nil for a created task
+// and after is nil for a deleted one. It must be called with the transaction that made the change.
+func recordTaskHistory(ctx context.Context, q queryer, dbType string, action entity.TaskHistoryAction, taskID int64, before, after *entity.Task) error {
+	var query string
+	if dbType == "mysql" {
+		query = `
+			INSERT INTO task_history (task_id, action, field, old_value, new_value, actor, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`
+	} else {
+		query = `
+			INSERT INTO task_history (task_id, action, field, old_value, new_value, actor, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	}
+
+	var oldFields, newFields []taskField
+	if before != nil {
+		oldFields = taskHistoryFields(before)
+	}
+	if after != nil {
+		newFields = taskHistoryFields(after)
+	}
+
+	actor := common.ActorFromContext(ctx)
+	now := time.Now()
+	for i := 0; i < len(oldFields) || i < len(newFields); i++ {
+		var name string
+		var oldValue, newValue *string
+		if before != nil {
+			name, oldValue = oldFields[i].name, oldFields[i].value
+		}
+		if after != nil {
+			name, newValue = newFields[i].name, newFields[i].value
+		}
+		if equalValues(oldValue, newValue) {
+			continue
+		}
+
+		if _, err := q.ExecContext(ctx, query, taskID, action, name, oldValue, newValue, actor, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func equalValues(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// txScope is either a new transaction or, when a transaction is already open, a savepoint inside it.
+// Commit and Rollback behave like those of *sql.Tx, so callers use the same code in both cases.
+type txScope struct {
+	*sql.Tx
+	ctx       context.Context
+	savepoint string
+	done      bool
+}
+
+// beginScope starts a transaction on db, or a savepoint on tx when it is not nil.
+// seq numbers the savepoints so that their names are unique within the transaction.
+func beginScope(ctx context.Context, db *sql.DB, tx *sql.Tx, seq *int) (*txScope, error) {
+	if tx == nil {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &txScope{Tx: tx, ctx: ctx}, nil
+	}
+
+	*seq++
+	savepoint := fmt.Sprintf("sp_%d", *seq)
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return nil, err
+	}
+	return &txScope{Tx: tx, ctx: ctx, savepoint: savepoint}, nil
+}
+
+func (s *txScope) Commit() error {
+	s.done = true
+	if s.savepoint == "" {
+		return s.Tx.Commit()
+	}
+	_, err := s.Tx.ExecContext(s.ctx, "RELEASE SAVEPOINT "+s.savepoint)
+	return err
+}
+
+func (s *txScope) Rollback() error {
+	if s.done {
+		return nil
+	}
+	s.done = true
+	if s.savepoint == "" {
+		return s.Tx.Rollback()
+	}
+	_, err := s.Tx.ExecContext(s.ctx, "ROLLBACK TO SAVEPOINT "+s.savepoint)
+	return err
+}
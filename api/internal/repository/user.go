@@ -3,10 +3,13 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/kenwoo9y/todo-api-go/api/internal/config"
 	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
 )
 
 type UserRepository interface {
@@ -15,9 +18,15 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id int64) (*entity.User, error)
 	GetByUsername(ctx context.Context, username string) (*entity.User, error)
 	Update(ctx context.Context, user *entity.User) error
-	Delete(ctx context.Context, id int64) error
+	// Delete moves the user and all of their tasks to the trash
+	Delete(ctx context.Context, id int64, version int64) error
+	GetDeleted(ctx context.Context) ([]entity.User, error)
+	Restore(ctx context.Context, id int64) (*entity.User, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 }
 
+const userSelectQuery = `SELECT id, username, email, first_name, last_name, created_at, updated_at, version, deleted_at FROM users`
+
 type userRepository struct {
 	db     *sql.DB
 	dbType string
@@ -44,6 +53,7 @@ func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
 	}
 
 	now := time.Now()
+	user.Version = 1
 	if r.dbType == "mysql" {
 		result, err := r.db.ExecContext(ctx,
 			query,
@@ -55,7 +65,7 @@ func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
 			now,
 		)
 		if err != nil {
-			return err
+			return r.uniqueError(ctx, translateError(err), user)
 		}
 
 		id, err := result.LastInsertId()
@@ -65,7 +75,7 @@ func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
 		user.ID = id
 		return nil
 	} else {
-		return r.db.QueryRowContext(ctx,
+		return r.uniqueError(ctx, translateError(r.db.QueryRowContext(ctx,
 			query,
 			user.Username,
 			user.Email,
@@ -73,55 +83,31 @@ func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
 			user.LastName,
 			now,
 			now,
-		).Scan(&user.ID)
+		).Scan(&user.ID)), user)
 	}
 }
 
 func (r *userRepository) GetAll(ctx context.Context) ([]entity.User, error) {
-	query := `SELECT * FROM users`
+	query := userSelectQuery + ` WHERE deleted_at IS NULL`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var users []entity.User
-	for rows.Next() {
-		var user entity.User
-		if err := rows.Scan(
-			&user.ID,
-			&user.Username,
-			&user.Email,
-			&user.FirstName,
-			&user.LastName,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		users = append(users, user)
-	}
-	return users, rows.Err()
+	return scanUsers(rows)
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id int64) (*entity.User, error) {
 	var user entity.User
 	var query string
 	if r.dbType == "mysql" {
-		query = `SELECT * FROM users WHERE id = ?`
+		query = userSelectQuery + ` WHERE id = ? AND deleted_at IS NULL`
 	} else {
-		query = `SELECT * FROM users WHERE id = $1`
+		query = userSelectQuery + ` WHERE id = $1 AND deleted_at IS NULL`
 	}
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.FirstName,
-		&user.LastName,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	err := scanUser(r.db.QueryRowContext(ctx, query, id), &user)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -132,20 +118,12 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*e
 	var user entity.User
 	var query string
 	if r.dbType == "mysql" {
-		query = `SELECT * FROM users WHERE username = ?`
+		query = userSelectQuery + ` WHERE username = ? AND deleted_at IS NULL`
 	} else {
-		query = `SELECT * FROM users WHERE username = $1`
+		query = userSelectQuery + ` WHERE username = $1 AND deleted_at IS NULL`
 	}
 
-	err := r.db.QueryRowContext(ctx, query, username).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.FirstName,
-		&user.LastName,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	err := scanUser(r.db.QueryRowContext(ctx, query, username), &user)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -157,34 +135,287 @@ func (r *userRepository) Update(ctx context.Context, user *entity.User) error {
 	if r.dbType == "mysql" {
 		query = `
 			UPDATE users
-			SET username = ?, email = ?, first_name = ?, last_name = ?, updated_at = ?
-			WHERE id = ?`
+			SET username = ?, email = ?, first_name = ?, last_name = ?, updated_at = ?, version = version + 1
+			WHERE id = ? AND version = ? AND deleted_at IS NULL`
 	} else {
 		query = `
 			UPDATE users
-			SET username = $1, email = $2, first_name = $3, last_name = $4, updated_at = $5
-			WHERE id = $6`
+			SET username = $1, email = $2, first_name = $3, last_name = $4, updated_at = $5, version = version + 1
+			WHERE id = $6 AND version = $7 AND deleted_at IS NULL`
 	}
 
-	_, err := r.db.ExecContext(ctx,
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx,
 		query,
 		user.Username,
 		user.Email,
 		user.FirstName,
 		user.LastName,
-		time.Now(),
+		now,
 		user.ID,
+		user.Version,
 	)
-	return err
+	if err != nil {
+		return r.uniqueError(ctx, translateError(err), user)
+	}
+	// The user was changed or deleted since it was read
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return r.missingOrModified(ctx, r.db, user.ID)
+	}
+	user.Version++
+	user.UpdatedAt = now
+	return nil
+}
+
+// Delete moves a user to the trash. A non-zero version makes the deletion conditional on the user
+// still being at that version; otherwise ErrPreconditionFailed is returned. ErrNotFound is returned
+// when there is no such user outside the trash.
+func (r *userRepository) Delete(ctx context.Context, id int64, version int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var userQuery, selectQuery, tasksQuery string
+	userArgs := []interface{}{now, id}
+	if r.dbType == "mysql" {
+		userQuery = `UPDATE users SET deleted_at = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL`
+		selectQuery = taskSelectQuery(r.dbType) + ` WHERE owner_id = ? AND deleted_at IS NULL FOR UPDATE`
+		tasksQuery = `UPDATE tasks SET deleted_at = ?, deleted_by_cascade = TRUE, updated_at = ?, version = version + 1 WHERE owner_id = ? AND deleted_at IS NULL`
+		if version != 0 {
+			userQuery += ` AND version = ?`
+			userArgs = append(userArgs, version)
+		}
+	} else {
+		userQuery = `UPDATE users SET deleted_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL`
+		selectQuery = taskSelectQuery(r.dbType) + ` WHERE owner_id = $1 AND deleted_at IS NULL FOR UPDATE`
+		tasksQuery = `UPDATE tasks SET deleted_at = $1, deleted_by_cascade = TRUE, updated_at = $2, version = version + 1 WHERE owner_id = $3 AND deleted_at IS NULL`
+		if version != 0 {
+			userQuery += ` AND version = $3`
+			userArgs = append(userArgs, version)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, userQuery, userArgs...)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return r.missingOrModified(ctx, tx, id)
+	}
+
+	// The tasks are marked so that restoring the user brings back exactly these tasks
+	tasks, err := queryTasks(ctx, tx, selectQuery, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, tasksQuery, now, now, id); err != nil {
+		return err
+	}
+	for i := range tasks {
+		if err := recordTaskHistory(ctx, tx, r.dbType, entity.TaskHistoryActionDelete, tasks[i].ID, &tasks[i], nil); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// missingOrModified explains why a write to a user matched no rows: the user is gone, or its
+// version no longer matches
+func (r *userRepository) missingOrModified(ctx context.Context, q queryer, id int64) error {
+	var query string
+	if r.dbType == "mysql" {
+		query = `SELECT 1 FROM users WHERE id = ? AND deleted_at IS NULL`
+	} else {
+		query = `SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL`
+	}
+
+	var exists int
+	err := q.QueryRowContext(ctx, query, id).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return common.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return common.ErrPreconditionFailed
 }
 
-func (r *userRepository) Delete(ctx context.Context, id int64) error {
+// uniqueError explains a unique violation on the username or email of user when the value is held
+// by a user in the trash, which keeps it until the user is purged
+func (r *userRepository) uniqueError(ctx context.Context, err error, user *entity.User) error {
+	var constraintErr *ConstraintError
+	if !errors.As(err, &constraintErr) || constraintErr.Kind != ConstraintUnique {
+		return err
+	}
+
+	var column, value string
+	switch constraintErr.Field {
+	case "username":
+		column, value = "username", user.Username
+	case "email":
+		column, value = "email", user.Email
+	default:
+		return err
+	}
 	var query string
 	if r.dbType == "mysql" {
-		query = `DELETE FROM users WHERE id = ?`
+		query = `SELECT 1 FROM users WHERE ` + column + ` = ? AND deleted_at IS NOT NULL`
 	} else {
-		query = `DELETE FROM users WHERE id = $1`
+		query = `SELECT 1 FROM users WHERE ` + column + ` = $1 AND deleted_at IS NOT NULL`
+	}
+
+	var exists int
+	if lookupErr := r.db.QueryRowContext(ctx, query, value).Scan(&exists); lookupErr != nil {
+		// Not in the trash, or the lookup failed, which leaves the plain conflict to report
+		return err
+	}
+	return fmt.Errorf("%w: %s belongs to a user in the trash", common.ErrAlreadyExistsInTrash, column)
+}
+
+// checkLiveUser returns an invalid reference error unless the user exists outside the trash. The
+// row is share-locked so that the user cannot be moved to the trash before the caller commits.
+func checkLiveUser(ctx context.Context, q queryer, dbType string, id int64) error {
+	var query string
+	if dbType == "mysql" {
+		query = `SELECT 1 FROM users WHERE id = ? AND deleted_at IS NULL FOR SHARE`
+	} else {
+		query = `SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL FOR SHARE`
+	}
+
+	var exists int
+	err := q.QueryRowContext(ctx, query, id).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return &ConstraintError{Kind: ConstraintForeignKey, Field: "owner_id", Err: fmt.Errorf("user %d does not exist or is in the trash", id)}
 	}
-	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
+
+func (r *userRepository) GetDeleted(ctx context.Context) ([]entity.User, error) {
+	query := userSelectQuery + ` WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUsers(rows)
+}
+
+// Restore takes a user out of the trash together with the tasks that were deleted with them.
+// It returns nil when no trashed user has the given id.
+func (r *userRepository) Restore(ctx context.Context, id int64) (*entity.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var user entity.User
+	var query string
+	if r.dbType == "mysql" {
+		query = userSelectQuery + ` WHERE id = ? AND deleted_at IS NOT NULL FOR UPDATE`
+	} else {
+		query = userSelectQuery + ` WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`
+	}
+	err = scanUser(tx.QueryRowContext(ctx, query, id), &user)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var userQuery, selectQuery, tasksQuery string
+	if r.dbType == "mysql" {
+		userQuery = `UPDATE users SET deleted_at = NULL, version = version + 1 WHERE id = ?`
+		selectQuery = taskSelectQuery(r.dbType) + ` WHERE owner_id = ? AND deleted_by_cascade = TRUE FOR UPDATE`
+		tasksQuery = `UPDATE tasks SET deleted_at = NULL, deleted_by_cascade = FALSE, updated_at = ?, version = version + 1 WHERE owner_id = ? AND deleted_by_cascade = TRUE`
+	} else {
+		userQuery = `UPDATE users SET deleted_at = NULL, version = version + 1 WHERE id = $1`
+		selectQuery = taskSelectQuery(r.dbType) + ` WHERE owner_id = $1 AND deleted_by_cascade = TRUE FOR UPDATE`
+		tasksQuery = `UPDATE tasks SET deleted_at = NULL, deleted_by_cascade = FALSE, updated_at = $1, version = version + 1 WHERE owner_id = $2 AND deleted_by_cascade = TRUE`
+	}
+	if _, err := tx.ExecContext(ctx, userQuery, id); err != nil {
+		return nil, err
+	}
+
+	tasks, err := queryTasks(ctx, tx, selectQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, tasksQuery, now, id); err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		task.DeletedAt = nil
+		task.UpdatedAt = now
+		task.Version++
+		if err := recordTaskHistory(ctx, tx, r.dbType, entity.TaskHistoryActionRestore, task.ID, nil, task); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	user.DeletedAt = nil
+	user.Version++
+	return &user, nil
+}
+
+// PurgeDeleted permanently removes users that were moved to the trash before the given time.
+// Users that still own tasks are kept until those tasks have been purged.
+func (r *userRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var query string
+	if r.dbType == "mysql" {
+		query = `
+			DELETE FROM users
+			WHERE deleted_at IS NOT NULL AND deleted_at < ?
+				AND NOT EXISTS (SELECT 1 FROM tasks WHERE tasks.owner_id = users.id)`
+	} else {
+		query = `
+			DELETE FROM users
+			WHERE deleted_at IS NOT NULL AND deleted_at < $1
+				AND NOT EXISTS (SELECT 1 FROM tasks WHERE tasks.owner_id = users.id)`
+	}
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanUsers(rows *sql.Rows) ([]entity.User, error) {
+	var users []entity.User
+	for rows.Next() {
+		var user entity.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func scanUser(row rowScanner, user *entity.User) error {
+	return row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.FirstName,
+		&user.LastName,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Version,
+		&user.DeletedAt,
+	)
+}
@@ -0,0 +1,162 @@
+// Package router dispatches requests by method and path pattern.
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+// Middleware wraps a handler. Middleware attached to a route runs only for that route.
+type Middleware func(http.Handler) http.Handler
+
+// Route maps a method and a path pattern to a handler. A {name} segment in the pattern matches any
+// single non-empty path segment, and the handler reads it with r.PathValue(name).
+type Route struct {
+	Method     string
+	Pattern    string
+	Handler    http.HandlerFunc
+	Middleware []Middleware
+}
+
+type route struct {
+	Route
+	segments []string
+	handler  http.Handler
+}
+
+// Router picks the most specific route for the path and method of a request. It answers 404 when
+// no pattern matches the path and 405 with an Allow header when patterns match the path but not
+// the method. A trailing slash is ignored, so /tasks/ is served like /tasks.
+type Router struct {
+	routes []route
+}
+
+func New(routes ...Route) *Router {
+	r := &Router{}
+	r.Handle(routes...)
+	return r
+}
+
+// Handle registers routes. The middleware of a route is applied in order, so the first one is the
+// outermost.
+func (rt *Router) Handle(routes ...Route) {
+	for _, r := range routes {
+		var handler http.Handler = r.Handler
+		for i := len(r.Middleware) - 1; i >= 0; i-- {
+			handler = r.Middleware[i](handler)
+		}
+		rt.routes = append(rt.routes, route{Route: r, segments: splitPath(r.Pattern), handler: handler})
+	}
+
+	// Sorting once makes the first match the most specific one
+	sort.SliceStable(rt.routes, func(i, j int) bool {
+		return moreSpecific(rt.routes[i].segments, rt.routes[j].segments)
+	})
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := splitPath(r.URL.Path)
+
+	var allowed []string
+	for _, route := range rt.routes {
+		params, ok := match(route.segments, segments)
+		if !ok {
+			continue
+		}
+		if route.Method != r.Method {
+			allowed = appendMethod(allowed, route.Method)
+			continue
+		}
+
+		for name, value := range params {
+			r.SetPathValue(name, value)
+		}
+		common.SetRoute(r.Context(), route.Pattern)
+		route.handler.ServeHTTP(w, r)
+		return
+	}
+
+	if len(allowed) == 0 {
+		common.HandleError(w, common.ErrNotFound)
+		return
+	}
+	sort.Strings(allowed)
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	common.ErrorJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed. allowed methods: "+strings.Join(allowed, ", "))
+}
+
+// Match returns the route that would serve r, without serving it, so that middleware running ahead
+// of the router can look up the route of a request
+func (rt *Router) Match(r *http.Request) (Route, bool) {
+	segments := splitPath(r.URL.Path)
+	for _, route := range rt.routes {
+		if route.Method != r.Method {
+			continue
+		}
+		if _, ok := match(route.segments, segments); ok {
+			return route.Route, true
+		}
+	}
+	return Route{}, false
+}
+
+func splitPath(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	path = strings.TrimSuffix(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+func match(pattern, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+
+	var params map[string]string
+	for i, segment := range pattern {
+		if isParam(segment) {
+			if segments[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[segment[1:len(segment)-1]] = segments[i]
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// moreSpecific orders patterns so that, at the first position where they differ, a literal segment
+// comes before a parameter. /users/username/{username} thus wins over /users/{id}/{sub}. Patterns of
+// different lengths never match the same path and are only ordered to keep the sort consistent.
+func moreSpecific(a, b []string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if pa, pb := isParam(a[i]), isParam(b[i]); pa != pb {
+			return !pa
+		}
+	}
+	return len(a) < len(b)
+}
+
+func appendMethod(methods []string, method string) []string {
+	for _, m := range methods {
+		if m == method {
+			return methods
+		}
+	}
+	return append(methods, method)
+}
@@ -0,0 +1,133 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	named := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Route", name)
+			w.Header().Set("X-ID", r.PathValue("id"))
+			w.Header().Set("X-Username", r.PathValue("username"))
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+
+	rt := New(
+		Route{Method: http.MethodGet, Pattern: "/users", Handler: named("list")},
+		Route{Method: http.MethodGet, Pattern: "/users/{id}", Handler: named("get")},
+		Route{Method: http.MethodPatch, Pattern: "/users/{id}", Handler: named("update")},
+		Route{Method: http.MethodDelete, Pattern: "/users/{id}", Handler: named("delete")},
+		Route{Method: http.MethodGet, Pattern: "/users/{id}/tasks", Handler: named("tasks")},
+		Route{Method: http.MethodGet, Pattern: "/users/username/{username}", Handler: named("username")},
+	)
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		expectedStatus   int
+		expectedRoute    string
+		expectedID       string
+		expectedUsername string
+		expectedAllow    string
+	}{
+		{name: "Success: Literal route", method: http.MethodGet, path: "/users", expectedStatus: http.StatusOK, expectedRoute: "list"},
+		{name: "Success: Path parameter", method: http.MethodGet, path: "/users/42", expectedStatus: http.StatusOK, expectedRoute: "get", expectedID: "42"},
+		{name: "Success: Method picks the route", method: http.MethodPatch, path: "/users/42", expectedStatus: http.StatusOK, expectedRoute: "update", expectedID: "42"},
+		{name: "Success: Nested route", method: http.MethodGet, path: "/users/42/tasks", expectedStatus: http.StatusOK, expectedRoute: "tasks", expectedID: "42"},
+		{name: "Success: Literal segment wins over a parameter", method: http.MethodGet, path: "/users/username/taro", expectedStatus: http.StatusOK, expectedRoute: "username", expectedUsername: "taro"},
+		{name: "Success: Trailing slash is ignored", method: http.MethodGet, path: "/users/42/", expectedStatus: http.StatusOK, expectedRoute: "get", expectedID: "42"},
+		{name: "Error: Unknown path", method: http.MethodGet, path: "/projects", expectedStatus: http.StatusNotFound},
+		{name: "Error: Empty path parameter", method: http.MethodGet, path: "/users//tasks", expectedStatus: http.StatusNotFound},
+		{name: "Error: Method not allowed", method: http.MethodPost, path: "/users/42", expectedStatus: http.StatusMethodNotAllowed, expectedAllow: "DELETE, GET, PATCH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			rt.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("X-Route"); got != tt.expectedRoute {
+				t.Errorf("expected route %q, got %q", tt.expectedRoute, got)
+			}
+			if got := w.Header().Get("X-ID"); got != tt.expectedID {
+				t.Errorf("expected id %q, got %q", tt.expectedID, got)
+			}
+			if got := w.Header().Get("X-Username"); got != tt.expectedUsername {
+				t.Errorf("expected username %q, got %q", tt.expectedUsername, got)
+			}
+			if got := w.Header().Get("Allow"); got != tt.expectedAllow {
+				t.Errorf("expected Allow %q, got %q", tt.expectedAllow, got)
+			}
+		})
+	}
+}
+
+func TestRouter_Middleware(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}
+
+	rt := New(
+		Route{Method: http.MethodPatch, Pattern: "/tasks/{id}", Handler: ok, Middleware: []Middleware{trace("outer"), trace("inner")}},
+		Route{Method: http.MethodGet, Pattern: "/tasks/{id}", Handler: ok},
+	)
+
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPatch, "/tasks/1", nil))
+	if got := len(order); got != 3 || order[0] != "outer" || order[1] != "inner" || order[2] != "handler" {
+		t.Errorf("expected outer, inner, handler, got %v", order)
+	}
+
+	order = nil
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tasks/1", nil))
+	if got := len(order); got != 1 || order[0] != "handler" {
+		t.Errorf("expected the middleware to be limited to its route, got %v", order)
+	}
+}
+
+func TestRouter_Match(t *testing.T) {
+	rt := New(
+		Route{Method: http.MethodGet, Pattern: "/users/{id}"},
+		Route{Method: http.MethodGet, Pattern: "/users/username/{username}"},
+	)
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		expectedPattern string
+		expectedOK      bool
+	}{
+		{name: "Success: Path parameter", method: http.MethodGet, path: "/users/42", expectedPattern: "/users/{id}", expectedOK: true},
+		{name: "Success: Literal segment wins over a parameter", method: http.MethodGet, path: "/users/username/taro", expectedPattern: "/users/username/{username}", expectedOK: true},
+		{name: "Error: Unknown path", method: http.MethodGet, path: "/projects"},
+		{name: "Error: Method not allowed", method: http.MethodPost, path: "/users/42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, ok := rt.Match(httptest.NewRequest(tt.method, tt.path, nil))
+			if ok != tt.expectedOK || route.Pattern != tt.expectedPattern {
+				t.Errorf("expected %q (%v), got %q (%v)", tt.expectedPattern, tt.expectedOK, route.Pattern, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/internal/tlsconfig"
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+// SystemActor is recorded in task history for changes made by background jobs
+const SystemActor = "system"
+
+// PurgeTrashJob permanently removes tasks and users that have been in the trash longer than retention
+func PurgeTrashJob(taskRepo repository.TaskRepository, userRepo repository.UserRepository, retention time.Duration, interval time.Duration) Job {
+	return Job{
+		Name:     "purge-trash",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			before := time.Now().Add(-retention)
+
+			tasks, err := taskRepo.PurgeDeleted(ctx, before)
+			if err != nil {
+				return err
+			}
+			users, err := userRepo.PurgeDeleted(ctx, before)
+			if err != nil {
+				return err
+			}
+
+			if tasks > 0 || users > 0 {
+				common.Logger(ctx).Info("purged trash", slog.Int64("tasks", tasks), slog.Int64("users", users))
+			}
+			return nil
+		},
+	}
+}
+
+// AutoArchiveJob archives tasks that have been Done for at least days. A zero days value disables the job.
+func AutoArchiveJob(taskRepo repository.TaskRepository, days int, interval time.Duration) Job {
+	if days <= 0 {
+		interval = 0
+	}
+
+	return Job{
+		Name:     "auto-archive",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			before := time.Now().AddDate(0, 0, -days)
+
+			archived, err := taskRepo.ArchiveDoneBefore(common.WithActor(ctx, SystemActor), before)
+			if err != nil {
+				return err
+			}
+
+			if archived > 0 {
+				common.Logger(ctx).Info("archived done tasks", slog.Int64("tasks", archived))
+			}
+			return nil
+		},
+	}
+}
+
+// PurgeIdempotencyKeysJob removes stored idempotent responses once their key has expired. A zero ttl
+// disables idempotency keys and with them the job.
+func PurgeIdempotencyKeysJob(idempotencyRepo repository.IdempotencyRepository, ttl time.Duration, interval time.Duration) Job {
+	if ttl <= 0 {
+		interval = 0
+	}
+
+	return Job{
+		Name:     "purge-idempotency-keys",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			purged, err := idempotencyRepo.PurgeExpired(ctx, time.Now())
+			if err != nil {
+				return err
+			}
+
+			if purged > 0 {
+				common.Logger(ctx).Info("purged expired idempotency keys", slog.Int64("keys", purged))
+			}
+			return nil
+		},
+	}
+}
+
+// ReloadCertificateJob loads the TLS certificate of the server again whenever its files change, so
+// that a renewed certificate is served without a restart
+func ReloadCertificateJob(reloader *tlsconfig.Reloader, interval time.Duration) Job {
+	return Job{
+		Name:     "reload-certificate",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			reloaded, err := reloader.Reload()
+			if err != nil {
+				return err
+			}
+
+			if reloaded {
+				common.Logger(ctx).Info("reloaded certificate", slog.Time("not_after", reloader.NotAfter()))
+			}
+			return nil
+		},
+	}
+}
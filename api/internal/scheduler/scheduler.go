@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/pkg/common"
+)
+
+// Job is a background task that runs periodically inside the server process
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add registers a job. Jobs with a non-positive interval are ignored so they can be disabled from config.
+func (s *Scheduler) Add(job Job) {
+	if job.Interval <= 0 {
+		return
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every registered job once immediately and then on its interval until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+
+			ctx := common.WithLogger(ctx, common.Logger(ctx).With(slog.String("job", job.Name)))
+
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+
+			for {
+				if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+					common.Logger(ctx).Error("job failed", slog.Any("error", err))
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(job)
+	}
+}
+
+// Wait blocks until all jobs have stopped after the context passed to Start is cancelled
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"github.com/kenwoo9y/todo-api-go/api/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SetupMetricsServer returns the server answering scrapes on the metrics port, or nil when metrics
+// are disabled. It serves nothing else, so that the port can be left unreachable from outside.
+func SetupMetricsServer(cfg *config.Config, registry *prometheus.Registry) *http.Server {
+	if cfg.MetricsPort == 0 {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler(registry))
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.MetricsPort),
+		Handler:           mux,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
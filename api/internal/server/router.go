@@ -1,52 +1,93 @@
 package server
 
 import (
+	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/kenwoo9y/todo-api-go/api/internal/config"
 	"github.com/kenwoo9y/todo-api-go/api/internal/handler"
 	"github.com/kenwoo9y/todo-api-go/api/internal/middleware"
+	"github.com/kenwoo9y/todo-api-go/api/internal/ratelimit"
+	"github.com/kenwoo9y/todo-api-go/api/internal/repository"
+	"github.com/kenwoo9y/todo-api-go/api/internal/router"
+	"github.com/prometheus/client_golang/prometheus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
-type customRouter struct {
-	userHandler *handler.UserHandler
-	taskHandler *handler.TaskHandler
+// versionedPatterns are the resources whose responses carry a version ETag
+var versionedPatterns = map[string]bool{
+	"/tasks/{id}": true,
+	"/users/{id}": true,
 }
 
-func (r *customRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	path := req.URL.Path
-
-	switch {
-	case path == "/users" || path == "/users/":
-		r.userHandler.ServeHTTP(w, req)
-	case strings.HasPrefix(path, "/users/username/"):
-		r.userHandler.ServeHTTP(w, req)
-	case strings.HasPrefix(path, "/users/") && strings.HasSuffix(path, "/tasks"):
-		r.taskHandler.ServeHTTP(w, req)
-	case strings.HasPrefix(path, "/users/"):
-		r.userHandler.ServeHTTP(w, req)
-	case path == "/tasks" || path == "/tasks/":
-		r.taskHandler.ServeHTTP(w, req)
-	case strings.HasPrefix(path, "/tasks/"):
-		r.taskHandler.ServeHTTP(w, req)
-	default:
-		http.Error(w, "Not Found", http.StatusNotFound)
+// routes collects the routes of every handler and attaches the route-level middleware
+func routes(cfg *config.Config, handlers ...interface{ Routes() []router.Route }) []router.Route {
+	var all []router.Route
+	for _, h := range handlers {
+		for _, route := range h.Routes() {
+			if cfg.RequireIfMatch && versionedPatterns[route.Pattern] && (route.Method == http.MethodPatch || route.Method == http.MethodDelete) {
+				route.Middleware = append(route.Middleware, middleware.RequireIfMatch)
+			}
+			all = append(all, route)
+		}
 	}
+	return all
 }
 
-func SetupServer(cfg *config.Config, userHandler *handler.UserHandler, taskHandler *handler.TaskHandler) *http.Server {
-	router := &customRouter{
-		userHandler: userHandler,
-		taskHandler: taskHandler,
-	}
+func SetupServer(cfg *config.Config, userHandler *handler.UserHandler, taskHandler *handler.TaskHandler, projectHandler *handler.ProjectHandler, taskHistoryHandler *handler.TaskHistoryHandler, trashHandler *handler.TrashHandler, calendarHandler *handler.CalendarHandler, healthHandler *handler.HealthHandler, idempotencyRepo repository.IdempotencyRepository, rateLimitStore ratelimit.Store, registry prometheus.Registerer, tracerProvider *sdktrace.TracerProvider) *http.Server {
+	mux := router.New(routes(cfg, userHandler, taskHandler, projectHandler, taskHistoryHandler, trashHandler, calendarHandler, healthHandler)...)
+	var handler http.Handler = mux
 
-	// Apply CORS middleware
+	rateLimitConfig := middleware.NewRateLimitConfig(cfg, rateLimitStore, mux)
+	cacheConfig := middleware.NewCacheControlConfig(cfg)
+	idempotencyConfig := middleware.NewIdempotencyConfig(cfg, idempotencyRepo)
 	corsConfig := middleware.NewCORSConfig(cfg)
-	handler := corsConfig.CORS(router)
+	metricsConfig := middleware.NewMetricsConfig(registry)
+	tracingConfig := middleware.NewTracingConfig(tracerProvider)
+	bodyLimitConfig := middleware.NewBodyLimitConfig(cfg)
+	compressConfig := middleware.NewCompressConfig(cfg)
+	timeoutConfig := middleware.NewTimeoutConfig(cfg)
+	available := map[string]func(http.Handler) http.Handler{
+		"request_id":       middleware.RequestID,
+		"tracing":          tracingConfig.Tracing,
+		"access_log":       middleware.AccessLog,
+		"metrics":          metricsConfig.Metrics,
+		"recover":          middleware.Recover,
+		"compress":         compressConfig.Compress,
+		"security_headers": middleware.SecurityHeaders,
+		"cors":             corsConfig.CORS,
+		"body_limit":       bodyLimitConfig.BodyLimit,
+		"timeout":          timeoutConfig.Timeout,
+		"actor":            middleware.Actor,
+		"rate_limit":       rateLimitConfig.RateLimit,
+		"idempotency":      idempotencyConfig.Idempotency,
+		"cache_control":    cacheConfig.CacheControl,
+	}
+
+	// Wrap the router in the configured order, outermost first
+	names := cfg.Middleware
+	if names == nil {
+		names = config.DefaultMiddleware
+	}
+	var chain middleware.Chain
+	for _, name := range names {
+		chain = append(chain, available[name])
+	}
+	handler = chain.Then(handler)
+
+	// Clients behind a trusted network can skip TLS and still multiplex requests over HTTP/2
+	if cfg.H2C {
+		handler = h2c.NewHandler(handler, &http2.Server{IdleTimeout: cfg.IdleTimeout})
+	}
 
 	return &http.Server{
-		Addr:    ":8080",
-		Handler: handler,
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
 	}
 }
@@ -0,0 +1,133 @@
+package taskio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+var csvHeader = []string{"id", "title", "description", "due_date", "status", "owner_id", "project_id", "archived", "created_at", "updated_at"}
+
+// csvRequiredColumns must appear in the header of an imported file
+var csvRequiredColumns = []string{"title", "due_date", "status", "owner_id"}
+
+type csvEncoder struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.wroteHeader {
+		return nil
+	}
+	e.wroteHeader = true
+	return e.w.Write(csvHeader)
+}
+
+func (e *csvEncoder) Encode(task *entity.Task) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	projectID := ""
+	if task.ProjectID != nil {
+		projectID = strconv.FormatInt(*task.ProjectID, 10)
+	}
+	return e.w.Write([]string{
+		strconv.FormatInt(task.ID, 10),
+		task.Title,
+		task.Description,
+		task.DueDate,
+		string(task.Status),
+		strconv.FormatInt(task.OwnerID, 10),
+		projectID,
+		strconv.FormatBool(task.Archived),
+		task.CreatedAt.Format(time.RFC3339),
+		task.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type csvDecoder struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing csv header")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, missingColumnError(name)
+		}
+	}
+	return &csvDecoder{r: reader, columns: columns}, nil
+}
+
+func (d *csvDecoder) Next() (*Record, error) {
+	row, err := d.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	field := func(name string) string {
+		i, ok := d.columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	record := &Record{
+		Title:       field("title"),
+		Description: field("description"),
+		DueDate:     field("due_date"),
+		Status:      entity.TaskStatus(field("status")),
+	}
+
+	if value := field("owner_id"); value != "" {
+		ownerID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, &RowError{Err: fmt.Errorf("invalid owner_id: %q", value)}
+		}
+		record.OwnerID = ownerID
+	}
+	if value := field("project_id"); value != "" {
+		projectID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, &RowError{Err: fmt.Errorf("invalid project_id: %q", value)}
+		}
+		record.ProjectID = &projectID
+	}
+	return record, nil
+}
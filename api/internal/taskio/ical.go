@@ -0,0 +1,183 @@
+package taskio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+// ICalComponent selects how tasks appear in a calendar
+type ICalComponent string
+
+const (
+	// ICalTodo renders tasks as VTODO entries for clients with task lists
+	ICalTodo ICalComponent = "vtodo"
+	// ICalEvent renders tasks as all-day VEVENT entries for clients that only show events
+	ICalEvent ICalComponent = "vevent"
+
+	ICalContentType = "text/calendar; charset=utf-8"
+
+	icalDateFormat     = "20060102"
+	icalDateTimeFormat = "20060102T150405Z"
+	icalLineLimit      = 75
+)
+
+var ErrUnsupportedICalComponent = fmt.Errorf("unsupported component. expected one of: %s, %s", ICalTodo, ICalEvent)
+
+// ParseICalComponent validates a component name. An empty name selects VTODO.
+func ParseICalComponent(name string) (ICalComponent, error) {
+	switch ICalComponent(strings.ToLower(name)) {
+	case "", ICalTodo:
+		return ICalTodo, nil
+	case ICalEvent:
+		return ICalEvent, nil
+	default:
+		return "", ErrUnsupportedICalComponent
+	}
+}
+
+// ICalUID returns the identifier of a task in calendar feeds. It never changes for a task, so
+// clients update entries in place instead of duplicating them.
+func ICalUID(taskID int64) string {
+	return "task-" + strconv.FormatInt(taskID, 10) + "@todo-api-go"
+}
+
+// WriteICalendar renders tasks as an RFC 5545 calendar. Tasks without a parsable due date are skipped.
+func WriteICalendar(w io.Writer, name string, component ICalComponent, tasks []entity.Task) error {
+	cw := &icalWriter{w: bufio.NewWriter(w)}
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:-//todo-api-go//Tasks//EN")
+	cw.line("CALSCALE:GREGORIAN")
+	cw.property("X-WR-CALNAME", escapeICalText(name))
+
+	for _, task := range tasks {
+		due, err := time.Parse("2006-01-02", task.DueDate)
+		if err != nil {
+			continue
+		}
+
+		if component == ICalEvent {
+			cw.line("BEGIN:VEVENT")
+		} else {
+			cw.line("BEGIN:VTODO")
+		}
+
+		cw.property("UID", ICalUID(task.ID))
+		cw.property("DTSTAMP", formatICalTime(task.UpdatedAt))
+		if !task.CreatedAt.IsZero() {
+			cw.property("CREATED", formatICalTime(task.CreatedAt))
+		}
+		if !task.UpdatedAt.IsZero() {
+			cw.property("LAST-MODIFIED", formatICalTime(task.UpdatedAt))
+		}
+		cw.property("SUMMARY", escapeICalText(task.Title))
+		if task.Description != "" {
+			cw.property("DESCRIPTION", escapeICalText(task.Description))
+		}
+
+		if component == ICalEvent {
+			cw.property("DTSTART;VALUE=DATE", due.Format(icalDateFormat))
+			cw.property("DTEND;VALUE=DATE", due.AddDate(0, 0, 1).Format(icalDateFormat))
+			cw.property("TRANSP", "TRANSPARENT")
+			cw.property("STATUS", icalEventStatus(task.Status))
+			cw.line("END:VEVENT")
+		} else {
+			cw.property("DUE;VALUE=DATE", due.Format(icalDateFormat))
+			cw.property("STATUS", icalTodoStatus(task.Status))
+			if task.Status == entity.TaskStatusDone && !task.UpdatedAt.IsZero() {
+				cw.property("COMPLETED", formatICalTime(task.UpdatedAt))
+				cw.property("PERCENT-COMPLETE", "100")
+			}
+			cw.line("END:VTODO")
+		}
+	}
+
+	cw.line("END:VCALENDAR")
+	if cw.err != nil {
+		return cw.err
+	}
+	return cw.w.Flush()
+}
+
+// icalTodoStatus maps a task status to the STATUS values defined for VTODO
+func icalTodoStatus(status entity.TaskStatus) string {
+	switch status {
+	case entity.TaskStatusDoing:
+		return "IN-PROCESS"
+	case entity.TaskStatusDone:
+		return "COMPLETED"
+	default:
+		return "NEEDS-ACTION"
+	}
+}
+
+// icalEventStatus maps a task status to the STATUS values defined for VEVENT, which has no notion
+// of progress: tasks that have not been started are tentative and the rest are confirmed.
+func icalEventStatus(status entity.TaskStatus) string {
+	switch status {
+	case entity.TaskStatusDoing, entity.TaskStatusDone:
+		return "CONFIRMED"
+	default:
+		return "TENTATIVE"
+	}
+}
+
+func formatICalTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Unix(0, 0)
+	}
+	return t.UTC().Format(icalDateTimeFormat)
+}
+
+func escapeICalText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`;`, `\;`,
+		`,`, `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// icalWriter writes content lines terminated by CRLF and folded at 75 octets
+type icalWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (cw *icalWriter) property(name, value string) {
+	cw.line(name + ":" + value)
+}
+
+func (cw *icalWriter) line(s string) {
+	if cw.err != nil {
+		return
+	}
+
+	limit := icalLineLimit
+	for len(s) > limit {
+		// Never split a multi-byte UTF-8 sequence across lines
+		cut := limit
+		for cut > 0 && !isUTF8Start(s[cut]) {
+			cut--
+		}
+		if _, cw.err = cw.w.WriteString(s[:cut] + "\r\n "); cw.err != nil {
+			return
+		}
+		s = s[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = icalLineLimit - 1
+	}
+	_, cw.err = cw.w.WriteString(s + "\r\n")
+}
+
+func isUTF8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
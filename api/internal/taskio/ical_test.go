@@ -0,0 +1,42 @@
+package taskio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+func TestWriteICalendar_EscapesAndFolds(t *testing.T) {
+	tasks := []entity.Task{
+		{ID: 1, Title: "会議; 資料, 準備", Description: strings.Repeat("とても長い説明", 10) + "\n二行目", DueDate: "2024-01-10", Status: entity.TaskStatusDoing},
+		{ID: 2, Title: "期日なし", DueDate: "invalid", Status: entity.TaskStatusTodo},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteICalendar(&buf, "tasks", ICalTodo, tasks); err != nil {
+		t.Fatalf("failed to write calendar: %v", err)
+	}
+	out := buf.String()
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > icalLineLimit {
+			t.Errorf("line longer than %d octets: %q", icalLineLimit, line)
+		}
+	}
+	if !strings.Contains(out, `SUMMARY:会議\; 資料\, 準備`) {
+		t.Errorf("expected escaped summary, got %q", out)
+	}
+	if !strings.Contains(out, "STATUS:IN-PROCESS") {
+		t.Errorf("expected IN-PROCESS status, got %q", out)
+	}
+	if strings.Contains(out, ICalUID(2)) {
+		t.Errorf("expected task without a valid due date to be skipped, got %q", out)
+	}
+
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	if !strings.Contains(unfolded, `\n二行目`) {
+		t.Errorf("expected escaped newline in description, got %q", unfolded)
+	}
+}
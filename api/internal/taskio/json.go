@@ -0,0 +1,126 @@
+package taskio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+// jsonEncoder writes a JSON array one element at a time
+type jsonEncoder struct {
+	w       io.Writer
+	started bool
+}
+
+func newJSONEncoder(w io.Writer) *jsonEncoder {
+	return &jsonEncoder{w: w}
+}
+
+func (e *jsonEncoder) Encode(task *entity.Task) error {
+	prefix := ","
+	if !e.started {
+		prefix = "["
+		e.started = true
+	}
+	if _, err := io.WriteString(e.w, prefix); err != nil {
+		return err
+	}
+	return json.NewEncoder(e.w).Encode(task)
+}
+
+func (e *jsonEncoder) Close() error {
+	if !e.started {
+		_, err := io.WriteString(e.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func newNDJSONEncoder(w io.Writer) *ndjsonEncoder {
+	return &ndjsonEncoder{enc: json.NewEncoder(w)}
+}
+
+func (e *ndjsonEncoder) Encode(task *entity.Task) error {
+	return e.enc.Encode(task)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// jsonDecoder reads the elements of a JSON array without loading the whole array
+type jsonDecoder struct {
+	dec  *json.Decoder
+	done bool
+}
+
+func newJSONDecoder(r io.Reader) (*jsonDecoder, error) {
+	dec := json.NewDecoder(r)
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("expected a json array of tasks")
+	}
+	return &jsonDecoder{dec: dec}, nil
+}
+
+func (d *jsonDecoder) Next() (*Record, error) {
+	if d.done || !d.dec.More() {
+		d.done = true
+		if _, err := d.dec.Token(); err != nil && err != io.EOF {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	var raw json.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return decodeJSONRecord(raw)
+}
+
+// ndjsonDecoder reads one JSON object per line. Blank lines are skipped.
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+}
+
+func newNDJSONDecoder(r io.Reader) *ndjsonDecoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return &ndjsonDecoder{scanner: scanner}
+}
+
+func (d *ndjsonDecoder) Next() (*Record, error) {
+	for d.scanner.Scan() {
+		line := bytes.TrimSpace(d.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		return decodeJSONRecord(line)
+	}
+	if err := d.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// decodeJSONRecord decodes a single element. An element that is not a task only fails its own row.
+func decodeJSONRecord(data []byte) (*Record, error) {
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, &RowError{Err: err}
+	}
+	return &record, nil
+}
@@ -0,0 +1,142 @@
+package taskio
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+// MarshalMarkdown renders a task as a GitHub-style checklist item. The fields other than the title
+// use the same key:value tags as todo.txt, and the description follows as indented lines so that it
+// renders as part of the item.
+func MarshalMarkdown(task *entity.Task) string {
+	box := "[ ]"
+	if task.Status == entity.TaskStatusDone {
+		box = "[x]"
+	}
+
+	var b strings.Builder
+	b.WriteString("- " + box + " " + singleLine(task.Title) + " " + strings.Join(taskTags(task), " "))
+	if task.Description != "" {
+		for _, line := range strings.Split(strings.ReplaceAll(task.Description, "\r\n", "\n"), "\n") {
+			b.WriteString("\n  " + line)
+		}
+	}
+	return b.String()
+}
+
+// parseMarkdownItem parses the first line of a checklist item. It reports false for lines that
+// are not checklist items.
+func parseMarkdownItem(line string) (*Record, bool, error) {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) < 2 || (trimmed[0] != '-' && trimmed[0] != '*' && trimmed[0] != '+') || trimmed[1] != ' ' {
+		return nil, false, nil
+	}
+
+	rest := strings.TrimLeft(trimmed[2:], " ")
+	if len(rest) < 3 || rest[0] != '[' || rest[2] != ']' {
+		return nil, false, nil
+	}
+
+	record := &Record{Status: entity.TaskStatusTodo}
+	switch rest[1] {
+	case ' ':
+	case 'x', 'X':
+		record.Status = entity.TaskStatusDone
+	default:
+		return nil, false, nil
+	}
+
+	title, err := parseTags(strings.Fields(rest[3:]), record)
+	if err != nil {
+		return nil, true, err
+	}
+	record.Title = title
+	return record, true, nil
+}
+
+type markdownEncoder struct {
+	w io.Writer
+}
+
+func (e *markdownEncoder) Encode(task *entity.Task) error {
+	_, err := io.WriteString(e.w, MarshalMarkdown(task)+"\n")
+	return err
+}
+
+func (e *markdownEncoder) Close() error {
+	return nil
+}
+
+// markdownDecoder reads the checklist items of a document. Headings, paragraphs and plain list
+// items are skipped, so a checklist can be imported straight from a README or an issue.
+type markdownDecoder struct {
+	scanner *bufio.Scanner
+	// pending is the first line of the next item, read while collecting the previous description
+	pending *string
+}
+
+func newMarkdownDecoder(r io.Reader) *markdownDecoder {
+	return &markdownDecoder{scanner: bufio.NewScanner(r)}
+}
+
+func (d *markdownDecoder) nextLine() (string, bool) {
+	if d.pending != nil {
+		line := *d.pending
+		d.pending = nil
+		return line, true
+	}
+	if !d.scanner.Scan() {
+		return "", false
+	}
+	return d.scanner.Text(), true
+}
+
+func (d *markdownDecoder) Next() (*Record, error) {
+	for {
+		line, ok := d.nextLine()
+		if !ok {
+			if err := d.scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+
+		record, isItem, err := parseMarkdownItem(line)
+		if !isItem {
+			continue
+		}
+
+		description := d.readDescription()
+		if err != nil {
+			return nil, err
+		}
+		record.Description = description
+		return record, nil
+	}
+}
+
+// readDescription collects the lines indented under an item
+func (d *markdownDecoder) readDescription() string {
+	var lines []string
+	for d.scanner.Scan() {
+		line := d.scanner.Text()
+		if !strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "\t") {
+			d.pending = &line
+			break
+		}
+		if _, isItem, _ := parseMarkdownItem(line); isItem {
+			// A nested checklist item is a task of its own
+			d.pending = &line
+			break
+		}
+		if strings.HasPrefix(line, "\t") {
+			lines = append(lines, line[1:])
+		} else {
+			lines = append(lines, line[2:])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,85 @@
+package taskio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+func TestMarkdown_RoundTrip(t *testing.T) {
+	projectID := int64(3)
+	tasks := []entity.Task{
+		{Title: "電話する +家族", DueDate: "2024-01-10", Status: entity.TaskStatusTodo, OwnerID: 1},
+		{Title: "資料作成", Description: "第1章\n\n- 図を追加", DueDate: "2024-01-11", Status: entity.TaskStatusDoing, OwnerID: 2, ProjectID: &projectID},
+		{Title: "買い物", DueDate: "2024-01-12", Status: entity.TaskStatusDone, OwnerID: 1},
+	}
+	expected := "- [ ] 電話する +家族 due:2024-01-10 owner:1\n" +
+		"- [ ] 資料作成 due:2024-01-11 status:Doing owner:2 project:3\n" +
+		"  第1章\n" +
+		"  \n" +
+		"  - 図を追加\n" +
+		"- [x] 買い物 due:2024-01-12 owner:1\n"
+
+	var buf bytes.Buffer
+	enc, _ := NewEncoder(&buf, FormatMarkdown)
+	for i := range tasks {
+		if err := enc.Encode(&tasks[i]); err != nil {
+			t.Fatalf("failed to encode: %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("failed to close encoder: %v", err)
+	}
+	if buf.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, buf.String())
+	}
+
+	dec, _ := NewDecoder(strings.NewReader(expected), FormatMarkdown)
+	var again bytes.Buffer
+	for i := range tasks {
+		record, err := dec.Next()
+		if err != nil {
+			t.Fatalf("failed to decode item %d: %v", i, err)
+		}
+		assertRecordMatchesTask(t, record, &tasks[i], true)
+		again.WriteString(MarshalMarkdown(recordTask(record)) + "\n")
+	}
+	if _, err := dec.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after the last item, got %v", err)
+	}
+	if again.String() != expected {
+		t.Errorf("expected %q after a round trip, got %q", expected, again.String())
+	}
+}
+
+func TestMarkdownDecoder_SkipsOtherContent(t *testing.T) {
+	input := "# 今週のタスク\n\n" +
+		"説明の段落\n\n" +
+		"- 普通のリスト項目\n" +
+		"* [X] 完了済み due:2024-01-10 owner:1\n" +
+		"- [ ] 親タスク due:2024-01-11 owner:1\n" +
+		"  - [ ] 子タスク due:2024-01-12 owner:1\n"
+
+	dec, _ := NewDecoder(strings.NewReader(input), FormatMarkdown)
+	var titles []string
+	for {
+		record, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to decode: %v", err)
+		}
+		if record.Title == "完了済み" && record.Status != entity.TaskStatusDone {
+			t.Errorf("expected [X] to mark the task done, got %s", record.Status)
+		}
+		titles = append(titles, record.Title)
+	}
+
+	if strings.Join(titles, ",") != "完了済み,親タスク,子タスク" {
+		t.Errorf("unexpected items: %v", titles)
+	}
+}
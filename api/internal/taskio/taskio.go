@@ -0,0 +1,136 @@
+// Package taskio encodes and decodes tasks in the formats used to move data between environments.
+package taskio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+type Format string
+
+const (
+	FormatCSV      Format = "csv"
+	FormatJSON     Format = "json"
+	FormatNDJSON   Format = "ndjson"
+	FormatTodoTxt  Format = "todotxt"
+	FormatMarkdown Format = "markdown"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported format. expected one of: csv, json, ndjson, todotxt, markdown")
+
+// ParseFormat validates a format name. An empty name selects JSON.
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case "":
+		return FormatJSON, nil
+	case FormatCSV, FormatJSON, FormatNDJSON, FormatTodoTxt, FormatMarkdown:
+		return Format(name), nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ContentType returns the media type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatTodoTxt:
+		return "text/plain; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// Extension returns the file name extension of the format
+func (f Format) Extension() string {
+	switch f {
+	case FormatTodoTxt:
+		return "txt"
+	case FormatMarkdown:
+		return "md"
+	default:
+		return string(f)
+	}
+}
+
+// Record is a task read from an import file. Fields that are not part of a new task, such as id
+// or timestamps from an earlier export, are ignored.
+type Record struct {
+	Title       string            `json:"title" validate:"required,max=30"`
+	Description string            `json:"description" validate:"max=255"`
+	DueDate     string            `json:"due_date" validate:"required,date"`
+	Status      entity.TaskStatus `json:"status" validate:"required,oneof=ToDo Doing Done"`
+	OwnerID     int64             `json:"owner_id" validate:"required,min=1"`
+	ProjectID   *int64            `json:"project_id" validate:"min=1"`
+}
+
+// RowError reports a row that could not be read. Decoding can continue with the next row.
+type RowError struct {
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Encoder writes tasks one at a time. Close must be called to finish the document.
+type Encoder interface {
+	Encode(task *entity.Task) error
+	Close() error
+}
+
+// Decoder reads records one at a time. Next returns io.EOF after the last record and a *RowError
+// for a row that is malformed but does not prevent reading the rest of the input.
+type Decoder interface {
+	Next() (*Record, error)
+}
+
+func NewEncoder(w io.Writer, format Format) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVEncoder(w), nil
+	case FormatJSON:
+		return newJSONEncoder(w), nil
+	case FormatNDJSON:
+		return newNDJSONEncoder(w), nil
+	case FormatTodoTxt:
+		return &todoTxtEncoder{w: w}, nil
+	case FormatMarkdown:
+		return &markdownEncoder{w: w}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func NewDecoder(r io.Reader, format Format) (Decoder, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVDecoder(r)
+	case FormatJSON:
+		return newJSONDecoder(r)
+	case FormatNDJSON:
+		return newNDJSONDecoder(r), nil
+	case FormatTodoTxt:
+		return newTodoTxtDecoder(r), nil
+	case FormatMarkdown:
+		return newMarkdownDecoder(r), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func missingColumnError(column string) error {
+	return fmt.Errorf("missing required column: %s", column)
+}
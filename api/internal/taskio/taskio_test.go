@@ -0,0 +1,90 @@
+package taskio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+func TestRoundTrip(t *testing.T) {
+	projectID := int64(3)
+	tasks := []entity.Task{
+		{ID: 1, Title: "タスク, \"引用\"", Description: "複数\n行", DueDate: "2024-01-01", Status: entity.TaskStatusTodo, OwnerID: 1, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{ID: 2, Title: "タスク2", DueDate: "2024-01-02", Status: entity.TaskStatusDone, OwnerID: 2, ProjectID: &projectID},
+	}
+
+	for _, format := range []Format{FormatCSV, FormatJSON, FormatNDJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := NewEncoder(&buf, format)
+			if err != nil {
+				t.Fatalf("failed to create encoder: %v", err)
+			}
+			for i := range tasks {
+				if err := enc.Encode(&tasks[i]); err != nil {
+					t.Fatalf("failed to encode: %v", err)
+				}
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatalf("failed to close encoder: %v", err)
+			}
+
+			dec, err := NewDecoder(&buf, format)
+			if err != nil {
+				t.Fatalf("failed to create decoder: %v", err)
+			}
+			for i, task := range tasks {
+				record, err := dec.Next()
+				if err != nil {
+					t.Fatalf("failed to decode record %d: %v", i, err)
+				}
+				if record.Title != task.Title || record.Description != task.Description || record.DueDate != task.DueDate ||
+					record.Status != task.Status || record.OwnerID != task.OwnerID {
+					t.Errorf("record %d does not match task: %+v", i, record)
+				}
+				if (record.ProjectID == nil) != (task.ProjectID == nil) || (record.ProjectID != nil && *record.ProjectID != *task.ProjectID) {
+					t.Errorf("record %d has project %v, expected %v", i, record.ProjectID, task.ProjectID)
+				}
+			}
+			if _, err := dec.Next(); err != io.EOF {
+				t.Errorf("expected io.EOF after the last record, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEmptyExport(t *testing.T) {
+	for format, expected := range map[Format]string{
+		FormatCSV:    "id,title,description,due_date,status,owner_id,project_id,archived,created_at,updated_at\n",
+		FormatJSON:   "[]\n",
+		FormatNDJSON: "",
+	} {
+		var buf bytes.Buffer
+		enc, _ := NewEncoder(&buf, format)
+		if err := enc.Close(); err != nil {
+			t.Fatalf("failed to close %s encoder: %v", format, err)
+		}
+		if buf.String() != expected {
+			t.Errorf("expected empty %s export %q, got %q", format, expected, buf.String())
+		}
+	}
+}
+
+func TestDecoder_RowError(t *testing.T) {
+	dec, err := NewDecoder(bytes.NewBufferString("title,due_date,status,owner_id\nタスク,2024-01-01,ToDo,abc\n"), FormatCSV)
+	if err != nil {
+		t.Fatalf("failed to create decoder: %v", err)
+	}
+
+	var rowErr *RowError
+	if _, err := dec.Next(); !errors.As(err, &rowErr) {
+		t.Errorf("expected a row error, got %v", err)
+	}
+	if _, err := dec.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after the last record, got %v", err)
+	}
+}
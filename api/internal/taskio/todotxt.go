@@ -0,0 +1,166 @@
+package taskio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+// Fields without a native todo.txt representation are written as key:value tags, which the
+// format reserves for extensions. +project and @context words are part of the text and stay in
+// the title as they are.
+const (
+	tagDue     = "due"
+	tagOwner   = "owner"
+	tagProject = "project"
+	tagStatus  = "status"
+)
+
+var (
+	todoTxtPriority = regexp.MustCompile(`^\([A-Z]\)$`)
+	todoTxtDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// MarshalTodoTxt renders a task as a single todo.txt line. Done tasks are marked complete with the
+// date of their last update. The description has no place in todo.txt and is left out.
+func MarshalTodoTxt(task *entity.Task) string {
+	var parts []string
+	if task.Status == entity.TaskStatusDone {
+		parts = append(parts, "x")
+		if !task.UpdatedAt.IsZero() {
+			parts = append(parts, task.UpdatedAt.Format("2006-01-02"))
+		}
+	}
+	if !task.CreatedAt.IsZero() {
+		parts = append(parts, task.CreatedAt.Format("2006-01-02"))
+	}
+	parts = append(parts, singleLine(task.Title))
+	return strings.Join(append(parts, taskTags(task)...), " ")
+}
+
+// UnmarshalTodoTxt parses a todo.txt line. Priorities and creation or completion dates are
+// accepted but not kept, since tasks have no fields for them.
+func UnmarshalTodoTxt(line string) (*Record, error) {
+	fields := strings.Fields(line)
+	record := &Record{Status: entity.TaskStatusTodo}
+
+	if len(fields) > 0 && fields[0] == "x" {
+		record.Status = entity.TaskStatusDone
+		fields = fields[1:]
+		// Completion date, then creation date
+		for i := 0; i < 2 && len(fields) > 0 && todoTxtDate.MatchString(fields[0]); i++ {
+			fields = fields[1:]
+		}
+	} else {
+		if len(fields) > 0 && todoTxtPriority.MatchString(fields[0]) {
+			fields = fields[1:]
+		}
+		if len(fields) > 0 && todoTxtDate.MatchString(fields[0]) {
+			fields = fields[1:]
+		}
+	}
+
+	title, err := parseTags(fields, record)
+	if err != nil {
+		return nil, err
+	}
+	record.Title = title
+	return record, nil
+}
+
+// taskTags returns the key:value tags that carry the fields of a task
+func taskTags(task *entity.Task) []string {
+	tags := []string{tagDue + ":" + task.DueDate}
+	if task.Status == entity.TaskStatusDoing {
+		tags = append(tags, tagStatus+":"+string(task.Status))
+	}
+	if task.OwnerID != 0 {
+		tags = append(tags, tagOwner+":"+strconv.FormatInt(task.OwnerID, 10))
+	}
+	if task.ProjectID != nil {
+		tags = append(tags, tagProject+":"+strconv.FormatInt(*task.ProjectID, 10))
+	}
+	return tags
+}
+
+// parseTags copies the known key:value tags in words onto record and returns the remaining words
+// as the title. A status tag does not override a status already set by the line itself.
+func parseTags(words []string, record *Record) (string, error) {
+	var title []string
+	for _, word := range words {
+		key, value, ok := strings.Cut(word, ":")
+		if !ok || value == "" {
+			title = append(title, word)
+			continue
+		}
+
+		switch key {
+		case tagDue:
+			record.DueDate = value
+		case tagStatus:
+			if record.Status != entity.TaskStatusDone {
+				record.Status = entity.TaskStatus(value)
+			}
+		case tagOwner:
+			ownerID, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return "", &RowError{Err: fmt.Errorf("invalid owner: %q", value)}
+			}
+			record.OwnerID = ownerID
+		case tagProject:
+			projectID, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return "", &RowError{Err: fmt.Errorf("invalid project: %q", value)}
+			}
+			record.ProjectID = &projectID
+		default:
+			title = append(title, word)
+		}
+	}
+	return strings.Join(title, " "), nil
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+type todoTxtEncoder struct {
+	w io.Writer
+}
+
+func (e *todoTxtEncoder) Encode(task *entity.Task) error {
+	_, err := io.WriteString(e.w, MarshalTodoTxt(task)+"\n")
+	return err
+}
+
+func (e *todoTxtEncoder) Close() error {
+	return nil
+}
+
+// todoTxtDecoder reads one task per line. Blank lines are skipped.
+type todoTxtDecoder struct {
+	scanner *bufio.Scanner
+}
+
+func newTodoTxtDecoder(r io.Reader) *todoTxtDecoder {
+	return &todoTxtDecoder{scanner: bufio.NewScanner(r)}
+}
+
+func (d *todoTxtDecoder) Next() (*Record, error) {
+	for d.scanner.Scan() {
+		line := strings.TrimSpace(d.scanner.Text())
+		if line == "" {
+			continue
+		}
+		return UnmarshalTodoTxt(line)
+	}
+	if err := d.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,137 @@
+package taskio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/entity"
+)
+
+func TestTodoTxt_RoundTrip(t *testing.T) {
+	projectID := int64(3)
+	created := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		task     entity.Task
+		expected string
+	}{
+		{
+			name:     "ToDo task",
+			task:     entity.Task{Title: "電話する +家族 @自宅", DueDate: "2024-01-10", Status: entity.TaskStatusTodo, OwnerID: 1, CreatedAt: created},
+			expected: "2024-01-01 電話する +家族 @自宅 due:2024-01-10 owner:1",
+		},
+		{
+			name:     "Doing task in a project",
+			task:     entity.Task{Title: "資料作成 +仕事", DueDate: "2024-01-11", Status: entity.TaskStatusDoing, OwnerID: 2, ProjectID: &projectID},
+			expected: "資料作成 +仕事 due:2024-01-11 status:Doing owner:2 project:3",
+		},
+		{
+			name:     "Done task",
+			task:     entity.Task{Title: "買い物 @外出", DueDate: "2024-01-12", Status: entity.TaskStatusDone, OwnerID: 1, CreatedAt: created, UpdatedAt: updated},
+			expected: "x 2024-01-05 2024-01-01 買い物 @外出 due:2024-01-12 owner:1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := MarshalTodoTxt(&tt.task)
+			if line != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, line)
+			}
+
+			record, err := UnmarshalTodoTxt(line)
+			if err != nil {
+				t.Fatalf("failed to parse %q: %v", line, err)
+			}
+			assertRecordMatchesTask(t, record, &tt.task, false)
+
+			// Parsing and rendering again gives back the same line
+			task := recordTask(record)
+			task.CreatedAt, task.UpdatedAt = tt.task.CreatedAt, tt.task.UpdatedAt
+			if again := MarshalTodoTxt(task); again != line {
+				t.Errorf("expected %q after a round trip, got %q", line, again)
+			}
+		})
+	}
+}
+
+func TestUnmarshalTodoTxt(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected Record
+	}{
+		{
+			name:     "Priority and creation date are skipped",
+			line:     "(A) 2024-01-01 電話する +家族 @自宅 due:2024-01-10 owner:1",
+			expected: Record{Title: "電話する +家族 @自宅", DueDate: "2024-01-10", Status: entity.TaskStatusTodo, OwnerID: 1},
+		},
+		{
+			name:     "Completion marker wins over a status tag",
+			line:     "x 2024-01-05 片付け status:Doing due:2024-01-10 owner:1",
+			expected: Record{Title: "片付け", DueDate: "2024-01-10", Status: entity.TaskStatusDone, OwnerID: 1},
+		},
+		{
+			name:     "Unknown tags stay in the title",
+			line:     "会議 10:00 url:https://example.com due:2024-01-10 owner:1",
+			expected: Record{Title: "会議 10:00 url:https://example.com", DueDate: "2024-01-10", Status: entity.TaskStatusTodo, OwnerID: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := UnmarshalTodoTxt(tt.line)
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if *record != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, *record)
+			}
+		})
+	}
+}
+
+func TestTodoTxtDecoder(t *testing.T) {
+	dec, err := NewDecoder(bytes.NewBufferString("タスク1 due:2024-01-10 owner:1\n\nタスク2 due:2024-01-11 owner:abc\n"), FormatTodoTxt)
+	if err != nil {
+		t.Fatalf("failed to create decoder: %v", err)
+	}
+
+	if record, err := dec.Next(); err != nil || record.Title != "タスク1" {
+		t.Errorf("expected the first task, got %+v, %v", record, err)
+	}
+	if _, err := dec.Next(); err == nil {
+		t.Error("expected a row error for an invalid owner")
+	}
+	if _, err := dec.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after the last line, got %v", err)
+	}
+}
+
+func recordTask(record *Record) *entity.Task {
+	return &entity.Task{
+		Title:       record.Title,
+		Description: record.Description,
+		DueDate:     record.DueDate,
+		Status:      record.Status,
+		OwnerID:     record.OwnerID,
+		ProjectID:   record.ProjectID,
+	}
+}
+
+func assertRecordMatchesTask(t *testing.T, record *Record, task *entity.Task, withDescription bool) {
+	t.Helper()
+	if record.Title != task.Title || record.DueDate != task.DueDate || record.Status != task.Status || record.OwnerID != task.OwnerID {
+		t.Errorf("record %+v does not match task %+v", record, task)
+	}
+	if withDescription && record.Description != task.Description {
+		t.Errorf("expected description %q, got %q", task.Description, record.Description)
+	}
+	if (record.ProjectID == nil) != (task.ProjectID == nil) || (record.ProjectID != nil && *record.ProjectID != *task.ProjectID) {
+		t.Errorf("expected project %v, got %v", task.ProjectID, record.ProjectID)
+	}
+}
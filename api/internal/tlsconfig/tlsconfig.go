@@ -0,0 +1,123 @@
+// Package tlsconfig builds the TLS configuration of the server and keeps its certificate up to date
+// with the files it was loaded from.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+)
+
+// New builds the server configuration from cfg, taking the certificate from reloader so that
+// renewed certificates are used by new connections without a restart
+func New(cfg *config.Config, reloader *Reloader) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:     cfg.TLSMinVersion,
+		ClientAuth:     cfg.TLSClientAuth,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if cfg.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in client CA %s", cfg.TLSClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// Reloader holds a certificate and loads it again from its files when they change
+type Reloader struct {
+	certFile, keyFile string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	version fileVersion
+}
+
+// fileVersion tells apart the contents of the certificate and key files without reading them
+type fileVersion struct {
+	certModTime, keyModTime time.Time
+	certSize, keySize       int64
+}
+
+// NewReloader loads the certificate, failing when the files cannot be read or do not match
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate. It is meant for tls.Config.GetCertificate.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Reload loads the certificate again when its files have changed since the last load, and reports
+// whether it did. The current certificate is kept when the new files cannot be loaded, such as
+// while a renewal has written the certificate but not yet the key; the next call tries again.
+func (r *Reloader) Reload() (bool, error) {
+	version, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && version == r.version
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.version = version
+	return true, nil
+}
+
+// NotAfter returns when the current certificate expires
+func (r *Reloader) NotAfter() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cert == nil || r.cert.Leaf == nil {
+		return time.Time{}
+	}
+	return r.cert.Leaf.NotAfter
+}
+
+func (r *Reloader) stat() (fileVersion, error) {
+	cert, err := os.Stat(r.certFile)
+	if err != nil {
+		return fileVersion{}, fmt.Errorf("failed to read certificate: %w", err)
+	}
+	key, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fileVersion{}, fmt.Errorf("failed to read certificate key: %w", err)
+	}
+	return fileVersion{
+		certModTime: cert.ModTime(),
+		keyModTime:  key.ModTime(),
+		certSize:    cert.Size(),
+		keySize:     key.Size(),
+	}, nil
+}
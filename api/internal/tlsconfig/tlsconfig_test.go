@@ -0,0 +1,256 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+)
+
+// testCert is a certificate signed by parent, or self-signed when parent is nil
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, name string, isCA bool, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// write stores the certificate and key, dated modTime so that reloads see them as changed
+func (c *testCert) write(t *testing.T, certFile, keyFile string, modTime time.Time) {
+	t.Helper()
+	for file, data := range map[string][]byte{certFile: c.certPEM, keyFile: c.keyPEM} {
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatalf("failed to date %s: %v", file, err)
+		}
+	}
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	now := time.Now()
+
+	first := newTestCert(t, "api.example.com", false, nil)
+	first.write(t, certFile, keyFile, now)
+	reloader, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("failed to load certificate: %v", err)
+	}
+
+	served := func() *x509.Certificate {
+		cert, err := reloader.GetCertificate(nil)
+		if err != nil {
+			t.Fatalf("failed to get certificate: %v", err)
+		}
+		return cert.Leaf
+	}
+
+	tests := []struct {
+		name             string
+		change           func()
+		expectedReloaded bool
+		expectedErr      bool
+		expectedCert     *testCert
+	}{
+		{
+			name:         "Success: Unchanged files",
+			change:       func() {},
+			expectedCert: first,
+		},
+		{
+			name: "Success: Renewed certificate",
+			change: func() {
+				newTestCert(t, "api.example.com", false, nil).write(t, certFile, keyFile, now.Add(time.Minute))
+			},
+			expectedReloaded: true,
+		},
+		{
+			name: "Error: Key not written yet",
+			change: func() {
+				renewed := newTestCert(t, "api.example.com", false, nil)
+				os.WriteFile(certFile, renewed.certPEM, 0o600)
+				os.Chtimes(certFile, now.Add(2*time.Minute), now.Add(2*time.Minute))
+			},
+			expectedErr: true,
+		},
+		{
+			name:             "Success: Key written",
+			change:           func() { first.write(t, certFile, keyFile, now.Add(3*time.Minute)) },
+			expectedReloaded: true,
+			expectedCert:     first,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := served()
+			tt.change()
+
+			reloaded, err := reloader.Reload()
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if reloaded != tt.expectedReloaded {
+				t.Errorf("expected reloaded %v, got %v", tt.expectedReloaded, reloaded)
+			}
+
+			after := served()
+			switch {
+			case tt.expectedCert != nil:
+				if !after.Equal(tt.expectedCert.cert) {
+					t.Error("expected the given certificate to be served")
+				}
+			case tt.expectedReloaded:
+				if after.Equal(before) {
+					t.Error("expected the renewed certificate to be served")
+				}
+			default:
+				if !after.Equal(before) {
+					t.Error("expected the previous certificate to be kept")
+				}
+			}
+		})
+	}
+}
+
+func TestNew_ClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+
+	ca := newTestCert(t, "社内CA", true, nil)
+	server := newTestCert(t, "api.example.com", false, ca)
+	server.write(t, certFile, keyFile, time.Now())
+	os.WriteFile(caFile, ca.certPEM, 0o600)
+	client := newTestCert(t, "client", false, ca)
+	stranger := newTestCert(t, "client", false, nil)
+
+	reloader, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("failed to load certificate: %v", err)
+	}
+	tlsConfig, err := New(&config.Config{
+		TLSMinVersion:   tls.VersionTLS12,
+		TLSClientAuth:   tls.RequireAndVerifyClientCert,
+		TLSClientCAFile: caFile,
+	}, reloader)
+	if err != nil {
+		t.Fatalf("failed to build config: %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = tlsConfig
+	srv.EnableHTTP2 = true
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		clientCert  *testCert
+		expectedErr bool
+	}{
+		{name: "Success: Certificate signed by the CA", clientCert: client},
+		{name: "Error: No certificate", expectedErr: true},
+		{name: "Error: Certificate signed by another CA", clientCert: stranger, expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roots := x509.NewCertPool()
+			roots.AddCert(ca.cert)
+			// httptest serves its own certificate to clients that send no server name
+			clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+			if tt.clientCert != nil {
+				cert, err := tls.X509KeyPair(tt.clientCert.certPEM, tt.clientCert.keyPEM)
+				if err != nil {
+					t.Fatalf("failed to load client certificate: %v", err)
+				}
+				clientConfig.Certificates = []tls.Certificate{cert}
+			}
+			httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig, ForceAttemptHTTP2: true}}
+
+			resp, err := httpClient.Get(srv.URL)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+			if resp.Header.Get("X-Proto") != "HTTP/2.0" {
+				t.Errorf("expected HTTP/2, got %s", resp.Header.Get("X-Proto"))
+			}
+		})
+	}
+}
+
+func TestNew_InvalidClientCA(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	os.WriteFile(caFile, []byte("証明書ではありません"), 0o600)
+
+	if _, err := New(&config.Config{TLSClientCAFile: caFile}, &Reloader{}); err == nil {
+		t.Error("expected an error for a client CA without certificates")
+	}
+	if _, err := New(&config.Config{TLSClientCAFile: caFile + ".missing"}, &Reloader{}); err == nil {
+		t.Error("expected an error for a missing client CA")
+	}
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RepositoryObserver starts a child span around every repository call, named after the repository
+// and the method, such as task.GetByID. It implements repository.Observer.
+type RepositoryObserver struct {
+	tracer   trace.Tracer
+	dbSystem string
+}
+
+// NewRepositoryObserver creates an observer recording to provider. A nil provider records nothing.
+func NewRepositoryObserver(provider *sdktrace.TracerProvider, cfg *config.Config) *RepositoryObserver {
+	// DB_TYPE is either mysql or postgresql, which are also the names OpenTelemetry uses for the dialects
+	o := &RepositoryObserver{dbSystem: cfg.DBType}
+	if provider != nil {
+		o.tracer = provider.Tracer(ScopeName)
+	}
+	return o
+}
+
+func (o *RepositoryObserver) Observe(ctx context.Context, repository, method string) (context.Context, func(err error)) {
+	if o.tracer == nil {
+		return ctx, func(error) {}
+	}
+
+	statement := repository + "." + method
+	ctx, span := o.tracer.Start(ctx, statement,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", o.dbSystem),
+			attribute.String("db.operation.name", method),
+			attribute.String("db.statement.name", statement),
+		),
+	)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
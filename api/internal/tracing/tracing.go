@@ -0,0 +1,53 @@
+// Package tracing sets up the OpenTelemetry SDK, which records spans and exports them to a collector
+// with OTLP over HTTP. Trace context is propagated with the W3C traceparent header.
+package tracing
+
+import (
+	"context"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ScopeName names the instrumentation that records the spans of this service
+const ScopeName = "github.com/kenwoo9y/todo-api-go/api"
+
+// Propagator reads and writes the traceparent and tracestate headers
+var Propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// New creates a tracer provider that exports to the configured OTLP endpoint in batches, and makes
+// it and Propagator the global ones. It returns nil, and with it no tracing, when no endpoint is
+// configured. The provider must be shut down to flush the spans that are still queued.
+func New(cfg *config.Config) (*sdktrace.TracerProvider, error) {
+	if cfg.TracingEndpoint == "" {
+		return nil, nil
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(cfg.TracingEndpoint),
+		otlptracehttp.WithHeaders(cfg.TracingHeaders),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := newTracerProvider(cfg, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(Propagator)
+	return provider, nil
+}
+
+// newTracerProvider keeps TracingSampleRatio of new traces, and follows the decision of the caller
+// for traces continued from another process
+func newTracerProvider(cfg *config.Config, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	opts = append(opts,
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.TracingServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracingSampleRatio))),
+	)
+	return sdktrace.NewTracerProvider(opts...)
+}
@@ -0,0 +1,178 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kenwoo9y/todo-api-go/api/internal/config"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// remoteContext continues the trace of a caller that sent a sampled traceparent header
+func remoteContext() context.Context {
+	header := http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}
+	return Propagator.Extract(context.Background(), propagation.HeaderCarrier(header))
+}
+
+func TestNew(t *testing.T) {
+	var mu sync.Mutex
+	var received []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r.Header.Clone())
+	}))
+	t.Cleanup(srv.Close)
+
+	provider, err := New(&config.Config{
+		TracingEndpoint:    srv.URL + "/v1/traces",
+		TracingHeaders:     map[string]string{"Authorization": "Bearer token"},
+		TracingServiceName: "todo-api-go",
+		TracingSampleRatio: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to create the provider: %v", err)
+	}
+
+	_, span := provider.Tracer(ScopeName).Start(context.Background(), "task.GetByID")
+	span.End()
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("failed to shut down: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 {
+		t.Fatalf("expected 1 export, got %d", len(received))
+	}
+	if received[0].Get("Authorization") != "Bearer token" {
+		t.Errorf("expected the configured headers to be sent, got %q", received[0].Get("Authorization"))
+	}
+}
+
+func TestNew_Disabled(t *testing.T) {
+	provider, err := New(&config.Config{TracingServiceName: "todo-api-go", TracingSampleRatio: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if provider != nil {
+		t.Error("expected no provider without an endpoint")
+	}
+}
+
+func TestNewTracerProvider_Sampling(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := newTracerProvider(&config.Config{TracingServiceName: "todo-api-go", TracingSampleRatio: 0}, sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer(ScopeName)
+
+	// New traces are dropped at ratio 0
+	_, root := tracer.Start(context.Background(), "root")
+	root.End()
+
+	// Traces continued from a sampled caller are kept
+	_, continued := tracer.Start(remoteContext(), "continued")
+	continued.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 1 || ended[0].Name() != "continued" {
+		t.Fatalf("expected only the continued trace to be sampled, got %d spans", len(ended))
+	}
+	if root.SpanContext().IsSampled() {
+		t.Error("expected the new trace not to be sampled")
+	}
+	if service, _ := ended[0].Resource().Set().Value("service.name"); service.AsString() != "todo-api-go" {
+		t.Errorf("expected the service name in the resource, got %q", service.AsString())
+	}
+}
+
+func TestRepositoryObserver(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := newTracerProvider(&config.Config{TracingServiceName: "todo-api-go", TracingSampleRatio: 1}, sdktrace.WithSpanProcessor(recorder))
+	observer := NewRepositoryObserver(provider, &config.Config{DBType: "postgresql"})
+
+	parent := remoteContext()
+	_, done := observer.Observe(parent, "task", "GetByID")
+	done(errors.New("タスクが見つかりません"))
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(ended))
+	}
+	span := ended[0]
+	if span.Name() != "task.GetByID" || span.SpanKind() != trace.SpanKindClient {
+		t.Errorf("unexpected span %s of kind %s", span.Name(), span.SpanKind())
+	}
+	if span.Parent().SpanID() != trace.SpanContextFromContext(parent).SpanID() {
+		t.Errorf("expected the span under the caller's span, got parent %s", span.Parent().SpanID())
+	}
+	if span.Status().Code != codes.Error || span.Status().Description != "タスクが見つかりません" {
+		t.Errorf("unexpected status %+v", span.Status())
+	}
+	attrs := map[string]string{}
+	for _, attr := range span.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.AsString()
+	}
+	if attrs["db.system"] != "postgresql" || attrs["db.statement.name"] != "task.GetByID" {
+		t.Errorf("unexpected attributes %v", attrs)
+	}
+}
+
+func TestRepositoryObserver_DBSystem(t *testing.T) {
+	tests := []struct {
+		name             string
+		dbType           string
+		expectedDBSystem string
+	}{
+		{
+			name:             "Success: MySQL",
+			dbType:           "mysql",
+			expectedDBSystem: "mysql",
+		},
+		{
+			name:             "Success: PostgreSQL",
+			dbType:           "postgresql",
+			expectedDBSystem: "postgresql",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			provider := newTracerProvider(&config.Config{TracingServiceName: "todo-api-go", TracingSampleRatio: 1}, sdktrace.WithSpanProcessor(recorder))
+			observer := NewRepositoryObserver(provider, &config.Config{DBType: tt.dbType})
+
+			_, done := observer.Observe(context.Background(), "task", "GetByID")
+			done(nil)
+
+			ended := recorder.Ended()
+			if len(ended) != 1 {
+				t.Fatalf("expected 1 span, got %d", len(ended))
+			}
+			attrs := map[string]string{}
+			for _, attr := range ended[0].Attributes() {
+				attrs[string(attr.Key)] = attr.Value.AsString()
+			}
+			if attrs["db.system"] != tt.expectedDBSystem {
+				t.Errorf("expected db.system %q, got %q", tt.expectedDBSystem, attrs["db.system"])
+			}
+		})
+	}
+}
+
+func TestRepositoryObserver_Disabled(t *testing.T) {
+	observer := NewRepositoryObserver(nil, &config.Config{DBType: "mysql"})
+	ctx, done := observer.Observe(context.Background(), "task", "GetByID")
+	done(nil)
+
+	if trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		t.Error("expected no span without a provider")
+	}
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Common function to build a strong ETag from a response body
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Common function to set the validators of a response and answer a conditional GET.
+// It returns true after writing 304 Not Modified, in which case the body must not be sent.
+// If-None-Match takes precedence over If-Modified-Since as required by RFC 9110.
+func CheckNotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" || !etagListMatches(inm, etag) {
+			return false
+		}
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil || lastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// etagListMatches reports whether an If-None-Match or If-Match header matches etag using weak comparison
+func etagListMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Common function to build the ETag of a versioned resource
+func VersionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// Common function to evaluate If-Match against the current version of a resource.
+// It returns ErrPreconditionFailed when the header names only other versions. Versions are
+// compared weakly, as the ETag of a compressed response is made weak and still names the version.
+func CheckIfMatch(r *http.Request, version int64) error {
+	header := r.Header.Get("If-Match")
+	if header == "" || etagListMatches(header, VersionETag(version)) {
+		return nil
+	}
+	return ErrPreconditionFailed
+}
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"context"
+	"log/slog"
+)
+
+type contextKey string
+
+const (
+	actorContextKey     contextKey = "actor"
+	requestIDContextKey contextKey = "request_id"
+	routeContextKey     contextKey = "route"
+	loggerContextKey    contextKey = "logger"
+)
+
+// AnonymousActor is recorded when a request does not identify its user
+const AnonymousActor = "anonymous"
+
+// Common function to attach the acting user to a context
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey, actor)
+}
+
+// Common function to read the acting user from a context
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorContextKey).(string); ok && actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
+
+// Common function to attach the request ID to a context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// Common function to read the request ID from a context
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
+// Common function to prepare a context in which the router can record the route it matched. Code
+// that wraps the router reads the route back with RouteFromContext once the request is served. A
+// context that is already prepared is returned as is, so that every wrapper sees the same route.
+func WithRoute(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(routeContextKey).(*string); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, routeContextKey, new(string))
+}
+
+// Common function to record the route pattern, such as /tasks/{id}, that served a request
+func SetRoute(ctx context.Context, pattern string) {
+	if route, ok := ctx.Value(routeContextKey).(*string); ok {
+		*route = pattern
+	}
+}
+
+// Common function to read the route pattern that served a request. It is empty when no route matched.
+func RouteFromContext(ctx context.Context) string {
+	if route, ok := ctx.Value(routeContextKey).(*string); ok {
+		return *route
+	}
+	return ""
+}
+
+// Common function to attach a logger to a context. Middleware adds the request ID and the user to
+// it, so that every line logged while serving a request names both.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, logger)
+}
+
+// Common function to read the logger of a context, falling back to the default logger
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -1,29 +1,143 @@
 package common
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 )
 
+// Error is an error that is reported to clients with its own status and a stable machine-readable code
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
 // Common error definitions
 var (
-	ErrInvalidPathFormat = errors.New("invalid path format")
-	ErrInvalidID         = errors.New("invalid id")
-	ErrInvalidOwnerID    = errors.New("invalid owner id")
-	ErrNotFound          = errors.New("not found")
-	ErrInternalServer    = errors.New("internal server error")
+	ErrInvalidID             = newError(http.StatusBadRequest, "invalid_id", "invalid id")
+	ErrInvalidOwnerID        = newError(http.StatusBadRequest, "invalid_owner_id", "invalid owner id")
+	ErrInvalidProjectID      = newError(http.StatusBadRequest, "invalid_project_id", "invalid project id")
+	ErrInvalidPagination     = newError(http.StatusBadRequest, "invalid_pagination", "invalid pagination parameters")
+	ErrInvalidQueryParameter = newError(http.StatusBadRequest, "invalid_query_parameter", "invalid query parameter")
+	ErrNoUpdateFields        = newError(http.StatusBadRequest, "no_update_fields", "at least one field must be provided for update")
+	ErrInvalidRequestBody    = newError(http.StatusBadRequest, "invalid_request_body", "invalid request body")
+	ErrInvalidBulkOperation  = newError(http.StatusBadRequest, "invalid_bulk_operation", "invalid bulk operation. expected op to be one of: create, update, delete")
+	ErrInvalidIdempotencyKey = newError(http.StatusBadRequest, "invalid_idempotency_key", "invalid Idempotency-Key header. expected at most 255 characters")
+	ErrValidationFailed      = newError(http.StatusBadRequest, "validation_failed", "the request contains invalid fields")
+	ErrForbidden             = newError(http.StatusForbidden, "forbidden", "forbidden")
+	ErrNotFound              = newError(http.StatusNotFound, "not_found", "not found")
+	ErrAlreadyExists         = newError(http.StatusConflict, "already_exists", "the resource already exists")
+	ErrAlreadyExistsInTrash  = newError(http.StatusConflict, "already_exists_in_trash", "the resource already exists in the trash. restore it or wait until it is purged")
+	ErrOwnerInTrash          = newError(http.StatusConflict, "owner_in_trash", "the owner of the task is in the trash. restore the owner first")
+	ErrIdempotencyInProgress = newError(http.StatusConflict, "idempotency_in_progress", "a request with this Idempotency-Key is still being processed. retry later")
+	ErrPreconditionFailed    = newError(http.StatusPreconditionFailed, "precondition_failed", "the resource has been modified. fetch it again and retry with its current ETag")
+	ErrRequestTooLarge       = newError(http.StatusRequestEntityTooLarge, "request_too_large", "the request body is too large")
+	ErrIdempotencyKeyReused  = newError(http.StatusUnprocessableEntity, "idempotency_key_reused", "this Idempotency-Key was already used with a different request")
+	ErrInvalidReference      = newError(http.StatusUnprocessableEntity, "invalid_reference", "the request refers to a resource that does not exist")
+	ErrRequiredField         = newError(http.StatusUnprocessableEntity, "required_field", "a required field is missing")
+	ErrValueTooLong          = newError(http.StatusUnprocessableEntity, "value_too_long", "a value is longer than allowed")
+	ErrPreconditionRequired  = newError(http.StatusPreconditionRequired, "precondition_required", "this request must be conditional. send an If-Match header with the ETag of the resource")
+	ErrRateLimited           = newError(http.StatusTooManyRequests, "rate_limited", "too many requests. retry after the number of seconds in the Retry-After header")
+	ErrInternalServer        = newError(http.StatusInternalServerError, "internal_server_error", "internal server error")
+	ErrRequestTimeout        = newError(http.StatusServiceUnavailable, "request_timeout", "the request took too long to process. retry later")
 )
 
+// FieldError describes why a single field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects the field errors of a request. It wraps ErrValidationFailed.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Common function to build a validation error from one or more field errors
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidationFailed
+}
+
+func (e *ValidationError) FieldErrors() []FieldError {
+	return e.Fields
+}
+
 // Common function to convert errors to appropriate HTTP responses
 func HandleError(w http.ResponseWriter, err error) {
-	switch {
-	case errors.Is(err, ErrInvalidPathFormat),
-		errors.Is(err, ErrInvalidID),
-		errors.Is(err, ErrInvalidOwnerID):
-		ErrorJSONResponse(w, http.StatusBadRequest, err.Error())
-	case errors.Is(err, ErrNotFound):
-		ErrorJSONResponse(w, http.StatusNotFound, err.Error())
-	default:
-		ErrorJSONResponse(w, http.StatusInternalServerError, ErrInternalServer.Error())
+	WriteProblem(w, ProblemFromError(err))
+}
+
+// Common function to map an error to its HTTP status and client-facing message
+func ErrorStatus(err error) (int, string) {
+	problem := ProblemFromError(err)
+	return problem.Status, problem.Detail
+}
+
+// Common function to build the problem details of an error. Errors that are not meant for clients
+// are reported as internal server errors without their message.
+func ProblemFromError(err error) *Problem {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		// Bodies decoded without DecodeJSON still report malformed JSON as a client error
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+			return NewProblem(ErrInvalidRequestBody.Status, ErrInvalidRequestBody.Code, ErrInvalidRequestBody.Message+": "+err.Error())
+		}
+		// So do bodies read past the limit of http.MaxBytesReader
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return ProblemFromError(requestTooLarge(tooLarge))
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return NewProblem(ErrRequestTimeout.Status, ErrRequestTimeout.Code, ErrRequestTimeout.Message)
+		}
+		return NewProblem(ErrInternalServer.Status, ErrInternalServer.Code, ErrInternalServer.Message)
 	}
+
+	if apiErr.Status >= http.StatusInternalServerError {
+		return NewProblem(apiErr.Status, apiErr.Code, apiErr.Message)
+	}
+
+	problem := NewProblem(apiErr.Status, apiErr.Code, err.Error())
+	var fields fieldErrorer
+	if errors.As(err, &fields) {
+		problem.Errors = fields.FieldErrors()
+	}
+	return problem
+}
+
+// requestTooLarge reports a body cut off by http.MaxBytesReader together with the limit it exceeded
+func requestTooLarge(err *http.MaxBytesError) error {
+	return fmt.Errorf("%w: the limit is %d bytes", ErrRequestTooLarge, err.Limit)
+}
+
+// fieldErrorer is implemented by errors that name the fields they were caused by
+type fieldErrorer interface {
+	FieldErrors() []FieldError
 }
@@ -0,0 +1,230 @@
+package common
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Media types a response can be negotiated into. Lists can be sent in any of them, everything else
+// only as JSON.
+const (
+	MediaTypeJSON   = "application/json"
+	MediaTypeNDJSON = "application/x-ndjson"
+	MediaTypeCSV    = "text/csv"
+)
+
+// Common function to pick the media type of a response from the Accept header of the request.
+// Offers are listed in the order the server prefers them, and the one the client weighs highest
+// wins. It returns false when the client accepts none of them.
+func Negotiate(r *http.Request, offers ...string) (string, bool) {
+	header := strings.Join(r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(header) == "" {
+		return offers[0], true
+	}
+
+	type acceptRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []acceptRange
+	for _, entry := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(entry))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		// The most specific range that matches an offer decides its quality
+		q, specificity := 0.0, -1
+		for _, ar := range ranges {
+			if s := acceptMatch(ar.mediaType, offer); s > specificity {
+				q, specificity = ar.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, best != ""
+}
+
+// acceptMatch reports how specifically a media range of an Accept header matches offer: 2 for the
+// exact type, 1 for type/*, 0 for */*, and -1 when it does not match
+func acceptMatch(mediaRange, offer string) int {
+	switch {
+	case mediaRange == offer:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	}
+	return -1
+}
+
+// negotiateBody encodes data in the media type the request asks for. Lists can be sent as JSON,
+// NDJSON or CSV; other data, and lists the client accepts in none of those, are sent as JSON.
+func negotiateBody(r *http.Request, data interface{}) (body []byte, contentType string, err error) {
+	offers := []string{MediaTypeJSON}
+	if isList(data) {
+		offers = append(offers, MediaTypeNDJSON, MediaTypeCSV)
+	}
+	mediaType, ok := Negotiate(r, offers...)
+	if !ok {
+		mediaType = MediaTypeJSON
+	}
+
+	switch mediaType {
+	case MediaTypeNDJSON:
+		body, err = encodeNDJSON(data)
+		return body, MediaTypeNDJSON, err
+	case MediaTypeCSV:
+		body, err = encodeCSV(data)
+		return body, MediaTypeCSV + "; charset=utf-8", err
+	default:
+		body, err = json.Marshal(data)
+		return append(body, '\n'), MediaTypeJSON, err
+	}
+}
+
+// isList reports whether data is a slice of structs, which is what list endpoints return
+func isList(data interface{}) bool {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return false
+	}
+	elem := v.Type().Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
+}
+
+// encodeNDJSON writes every element of a list as a JSON document on its own line
+func encodeNDJSON(data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	v := reflect.ValueOf(data)
+	for i := 0; i < v.Len(); i++ {
+		if err := enc.Encode(v.Index(i).Interface()); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// csvColumn is a field of a list element, named after its JSON key
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// encodeCSV writes a list with a header row of the JSON keys of its elements. Missing values are
+// left empty, times are written in RFC 3339, and nested values as JSON.
+func encodeCSV(data interface{}) ([]byte, error) {
+	v := reflect.ValueOf(data)
+	elem := v.Type().Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	columns := csvColumns(elem)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+	w.Write(header)
+
+	for i := 0; i < v.Len(); i++ {
+		item := reflect.Indirect(v.Index(i))
+		row := make([]string, len(columns))
+		for j, column := range columns {
+			if !item.IsValid() {
+				continue
+			}
+			field, err := item.FieldByIndexErr(column.index)
+			if err != nil {
+				// A nil embedded pointer leaves its fields empty
+				continue
+			}
+			cell, err := csvCell(field)
+			if err != nil {
+				return nil, err
+			}
+			row[j] = cell
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// csvColumns lists the fields encoding/json would write, in the same order and under the same names
+func csvColumns(t reflect.Type) []csvColumn {
+	var columns []csvColumn
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: field.Index})
+	}
+	return columns
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func csvCell(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Type() == timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339), nil
+	case v.Kind() == reflect.String:
+		return v.String(), nil
+	case v.Kind() == reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case v.CanInt():
+		return strconv.FormatInt(v.Int(), 10), nil
+	case v.CanUint():
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case v.CanFloat():
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	}
+
+	body, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s as csv: %w", v.Type(), err)
+	}
+	return string(body), nil
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"net/http"
+	"strconv"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Common paginated list response structure
+type PaginatedResponse struct {
+	Items  interface{} `json:"items"`
+	Total  int64       `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// Common function to extract limit and offset query parameters
+func ExtractPagination(r *http.Request) (limit int, offset int, err error) {
+	limit = DefaultPageLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxPageLimit {
+			return 0, 0, ErrInvalidPagination
+		}
+	}
+
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, ErrInvalidPagination
+		}
+	}
+
+	return limit, offset, nil
+}
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the media type of error responses defined by RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBase prefixes the code of a problem to form its type URI
+const ProblemTypeBase = "urn:todo-api-go:problem:"
+
+// RequestIDHeader carries the ID that correlates a response with the server logs
+const RequestIDHeader = "X-Request-ID"
+
+// Problem is the body of every error response. Code is stable and meant for programs, while Detail
+// is meant for people and may change.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Common function to build problem details for a status and code
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   ProblemTypeBase + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Common function to send problem details. The request ID is taken from the response header set
+// by the RequestID middleware, so that handlers do not need to pass the request along.
+func WriteProblem(w http.ResponseWriter, problem *Problem) {
+	if problem.RequestID == "" {
+		problem.RequestID = w.Header().Get(RequestIDHeader)
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// statusCode derives a code such as method_not_allowed from a status for errors without their own code
+func statusCode(status int) string {
+	text := strings.ToLower(http.StatusText(status))
+	if text == "" {
+		return "error"
+	}
+	text = strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text)
+	return text
+}
@@ -0,0 +1,111 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Common function to decode a JSON request body into v and check it with Validate. Malformed JSON,
+// unknown fields, values of the wrong type and trailing data are reported as client errors, with
+// the offending field where it is known.
+func DecodeJSON(r *http.Request, v interface{}) error {
+	return decodeJSON(r.Body, v)
+}
+
+// Common function to decode and validate a JSON document embedded in a request, such as the task
+// of a bulk operation, with the same rules as DecodeJSON
+func DecodeJSONBytes(data []byte, v interface{}) error {
+	return decodeJSON(bytes.NewReader(data), v)
+}
+
+func decodeJSON(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return fmt.Errorf("%w: the body must contain a single JSON value", ErrInvalidRequestBody)
+	}
+	return Validate(v)
+}
+
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return requestTooLarge(tooLarge)
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("%w: the body must not be empty", ErrInvalidRequestBody)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("%w: the body ends in the middle of a JSON value", ErrInvalidRequestBody)
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("%w: malformed JSON at offset %d", ErrInvalidRequestBody, syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Errorf("%w: expected a JSON %s", ErrInvalidRequestBody, jsonKind(typeErr))
+		}
+		return NewValidationError(FieldError{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: fmt.Sprintf("%s must be a %s", typeErr.Field, jsonKind(typeErr)),
+		})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		return NewValidationError(FieldError{Field: field, Code: "unknown_field", Message: field + " is not a known field"})
+	default:
+		return fmt.Errorf("%w: %v", ErrInvalidRequestBody, err)
+	}
+}
+
+// jsonKind names the JSON type expected by a failed unmarshal
+func jsonKind(err *json.UnmarshalTypeError) string {
+	switch err.Type.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return err.Type.String()
+	}
+}
+
+// Nullable is a field of a PATCH body that tells a value sent as null, which clears the field,
+// apart from a field left out of the body, which keeps its current value
+type Nullable[T any] struct {
+	Set   bool // the field is present in the body
+	Value *T   // nil when the field was sent as null
+}
+
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(data) == "null" {
+		n.Value = nil
+		return nil
+	}
+	n.Value = new(T)
+	return json.Unmarshal(data, n.Value)
+}
+
+// validateValue lets Validate check the value of a Nullable like that of a pointer field
+func (n Nullable[T]) validateValue() reflect.Value {
+	return reflect.ValueOf(n.Value)
+}
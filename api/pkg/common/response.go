@@ -1,27 +1,51 @@
 package common
 
 import (
-	"encoding/json"
 	"net/http"
+	"time"
 )
 
-// Common error response structure
-type ErrorResponse struct {
-	Message string `json:"message"`
-}
+// Common function to send JSON responses. Lists are sent as NDJSON or CSV instead when the Accept
+// header of the request prefers them.
+func JSONResponse(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	body, contentType, err := negotiateBody(r, data)
+	if err != nil {
+		HandleError(w, err)
+		return
+	}
+	if isList(data) {
+		w.Header().Add("Vary", "Accept")
+	}
 
-// Common function to send JSON responses
-func JSONResponse(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
+	w.Write(body)
 }
 
-// Common function to send error responses
+// Common function to send error responses for errors that have no code of their own
 func ErrorJSONResponse(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(ErrorResponse{
-		Message: message,
-	})
+	WriteProblem(w, NewProblem(status, statusCode(status), message))
+}
+
+// Common function to send a 200 JSON response that answers If-None-Match. The ETag fingerprints the
+// encoded body, so it changes whenever any part of the response does, including the media type
+// negotiated as by JSONResponse. No Last-Modified is sent, as the update times of the items in a
+// listing do not change when an item leaves it.
+func ConditionalJSONResponse(w http.ResponseWriter, r *http.Request, data interface{}) {
+	body, contentType, err := negotiateBody(r, data)
+	if err != nil {
+		HandleError(w, err)
+		return
+	}
+	if isList(data) {
+		w.Header().Add("Vary", "Accept")
+	}
+
+	if CheckNotModified(w, r, ETag(body), time.Time{}) {
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
 }
@@ -0,0 +1,164 @@
+package common
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// Common function to check a struct against the rules in its validate tags. Every violation is
+// collected into a single *ValidationError named after the json field. The rules are:
+//
+//	required      the value must not be empty
+//	max=N, min=N  the length of a string or slice, or the value of a number
+//	email         an address such as taro@example.com
+//	date          a date in YYYY-MM-DD format
+//	hexcolor      a color in #RRGGBB format
+//	oneof=A B C   one of the listed values
+//
+// Rules other than required are skipped for empty values, and every rule is skipped for nil
+// pointers, so that optional fields and fields absent from a PATCH body are not checked. A pointer
+// to an empty value was sent explicitly, so it is checked against every rule. A Nullable is checked
+// like a pointer to its value.
+func Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []FieldError
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		tag := rt.Field(i).Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		value := rv.Field(i)
+		if nullable, ok := value.Interface().(interface{ validateValue() reflect.Value }); ok {
+			value = nullable.validateValue()
+		}
+		explicit := false
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+			value, explicit = value.Elem(), true
+		}
+
+		name := jsonFieldName(rt.Field(i))
+		for _, rule := range strings.Split(tag, ",") {
+			if fieldErr := checkRule(name, rule, value, explicit); fieldErr != nil {
+				fields = append(fields, *fieldErr)
+				// Later rules are meaningless for a value that failed one
+				break
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return NewValidationError(fields...)
+	}
+	return nil
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func checkRule(name, rule string, value reflect.Value, explicit bool) *FieldError {
+	rule, arg, _ := strings.Cut(rule, "=")
+
+	if rule == "required" {
+		if value.IsZero() || (value.Kind() == reflect.Slice && value.Len() == 0) {
+			return &FieldError{Field: name, Code: "required", Message: name + " is required"}
+		}
+		return nil
+	}
+	if value.IsZero() && !explicit {
+		return nil
+	}
+
+	switch rule {
+	case "max", "min":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			panic(fmt.Sprintf("invalid validate rule %s=%s on %s", rule, arg, name))
+		}
+		return checkLimit(name, rule, limit, value)
+	case "email":
+		addr, err := mail.ParseAddress(value.String())
+		if err != nil || addr.Address != value.String() {
+			return &FieldError{Field: name, Code: "invalid_format", Message: name + " must be a valid email address"}
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value.String()); err != nil {
+			return &FieldError{Field: name, Code: "invalid_format", Message: name + " must be a date in YYYY-MM-DD format"}
+		}
+	case "hexcolor":
+		if !hexColorPattern.MatchString(value.String()) {
+			return &FieldError{Field: name, Code: "invalid_format", Message: name + " must be a color in #RRGGBB format"}
+		}
+	case "oneof":
+		allowed := strings.Fields(arg)
+		for _, candidate := range allowed {
+			if fmt.Sprint(value.Interface()) == candidate {
+				return nil
+			}
+		}
+		return &FieldError{Field: name, Code: "invalid_value", Message: name + " must be one of: " + strings.Join(allowed, ", ")}
+	default:
+		panic(fmt.Sprintf("unknown validate rule %q on %s", rule, name))
+	}
+	return nil
+}
+
+func checkLimit(name, rule string, limit float64, value reflect.Value) *FieldError {
+	var n float64
+	var unit string
+	switch value.Kind() {
+	case reflect.String:
+		n, unit = float64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Slice:
+		n, unit = float64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(value.Int())
+	case reflect.Float32, reflect.Float64:
+		n = value.Float()
+	default:
+		panic(fmt.Sprintf("validate rule %s cannot check %s", rule, value.Kind()))
+	}
+
+	if rule == "max" && n > limit {
+		code := "too_large"
+		if unit != "" {
+			code = "too_long"
+		}
+		return &FieldError{Field: name, Code: code, Message: fmt.Sprintf("%s must be at most %v%s", name, limit, unit)}
+	}
+	if rule == "min" && n < limit {
+		code := "too_small"
+		if unit != "" {
+			code = "too_short"
+		}
+		return &FieldError{Field: name, Code: code, Message: fmt.Sprintf("%s must be at least %v%s", name, limit, unit)}
+	}
+	return nil
+}
@@ -3,22 +3,25 @@ package common
 import (
 	"net/http"
 	"strconv"
-	"strings"
 )
 
-// Common function to extract ID from URL path
-func ExtractIDFromPath(path string, prefix string) (int64, error) {
-	idStr := strings.TrimPrefix(path, prefix)
-	return strconv.ParseInt(idStr, 10, 64)
+// Common function to read a numeric path parameter such as the {id} in /tasks/{id}
+func PathID(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(r.PathValue(name), 10, 64)
 }
 
-// Common function to extract ownerID from /users/{id}/tasks path
-func ExtractOwnerIDFromPath(path string) (int64, error) {
-	pathParts := strings.Split(path, "/")
-	if len(pathParts) != 4 {
-		return 0, ErrInvalidPathFormat
+// Common function to extract an optional boolean query parameter such as ?include_archived=true
+func ExtractBoolQuery(r *http.Request, key string) (bool, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return false, nil
 	}
-	return strconv.ParseInt(pathParts[2], 10, 64)
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, ErrInvalidQueryParameter
+	}
+	return b, nil
 }
 
 // Common function to validate HTTP methods